@@ -11,7 +11,9 @@ require (
 
 require (
 	github.com/lemmego/api {{.Version "github.com/lemmego/api"}}
+	{{- if eq (.Option "queue") "true"}}
 	github.com/lemmego/queue {{.Version "github.com/lemmego/queue"}}
+	{{- end}}
 	{{- if .EnableAuth}}
 	github.com/lemmego/auth {{.Version "github.com/lemmego/auth"}}
 	{{- end}}
@@ -4,7 +4,9 @@ import (
 	"github.com/lemmego/api/app"
 	"github.com/lemmego/api/providers/fs"
 	"github.com/lemmego/api/providers/session"
+	{{- if eq (.Option "queue") "true"}}
 	"github.com/lemmego/queue"
+	{{- end}}
 	{{- if .InertiaProvider}}
 	"github.com/lemmego/inertia"
 	{{- end}}
@@ -23,7 +25,9 @@ func LoadProviders() []app.Provider {
 	return []app.Provider{
 		&fs.Provider{},
 		&session.Provider{},
+		{{- if eq (.Option "queue") "true"}}
 		&queue.Provider{},
+		{{- end}}
 		{{- if .InertiaProvider}}
 		&inertia.Provider{
 			Options: []inertia.Option{
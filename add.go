@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// addResult lists what applying a feature did to each file of the project.
+type addResult struct {
+	Created   []string
+	Updated   []string
+	Merged    []string
+	Conflicts []string // Written next to the file with a .lemmego-conflict suffix
+	Skipped   []string // Deleted in the project, left alone
+}
+
+// conflictSuffix is appended to the path of a file whose merge has conflicts.
+const conflictSuffix = ".lemmego-conflict"
+
+// featureValues returns values with the given feature enabled. Besides the
+// named features, any option of the scaffold manifest can be set with
+// name=value, or with just its name for a confirm option.
+func featureValues(m *ScaffoldManifest, feature string, values map[string]string) (map[string]string, error) {
+	values = maps.Clone(values)
+	frontend := FrontendPreset(values["frontend"])
+
+	switch feature {
+	case "auth", "redis", "queue":
+		values[feature] = "true"
+	case "templ":
+		switch frontend {
+		case FrontendGoTemplates:
+			values["frontend"] = string(FrontendTempl)
+		case FrontendInertiaReact:
+			values["frontend"] = string(FrontendTemplInertiaReact)
+		case FrontendInertiaVue:
+			values["frontend"] = string(FrontendTemplInertiaVue)
+		}
+	case "inertia-react", "inertia-vue":
+		react := feature == "inertia-react"
+		switch {
+		case frontend == FrontendGoTemplates && react:
+			values["frontend"] = string(FrontendInertiaReact)
+		case frontend == FrontendGoTemplates:
+			values["frontend"] = string(FrontendInertiaVue)
+		case frontend == FrontendTempl && react:
+			values["frontend"] = string(FrontendTemplInertiaReact)
+		case frontend == FrontendTempl:
+			values["frontend"] = string(FrontendTemplInertiaVue)
+		case frontend.HasInertia():
+			return nil, fmt.Errorf("the project already uses Inertia (%s)", frontend)
+		}
+	default:
+		name, value, hasValue := strings.Cut(feature, "=")
+		opt := m.Option(name)
+		if opt == nil {
+			return nil, fmt.Errorf("unknown feature %q (expected auth, redis, queue, inertia-react, inertia-vue, templ or an option of the scaffold manifest)", name)
+		}
+		if !hasValue {
+			if opt.Type != OptionConfirm {
+				return nil, fmt.Errorf("%s needs a value: lemmego add %s=<value>", name, name)
+			}
+			value = "true"
+		}
+		values[name] = value
+	}
+
+	if (feature == "templ" || strings.HasPrefix(feature, "inertia-")) && values["preset"] != string(PresetMVC) {
+		return nil, fmt.Errorf("%s needs the %s preset", feature, PresetMVC)
+	}
+	return values, nil
+}
+
+// applyScaffoldChange updates the project in projectDir from the scaffold
+// rendered for oldCfg to the scaffold rendered for newCfg. Files the project
+// left untouched are replaced, modified files get a three-way merge with the
+// old scaffold as the base, and merges with conflicts are written next to the
+// file instead of over it.
+func applyScaffoldChange(src scaffoldSource, origin ScaffoldOrigin, oldCfg, newCfg ProjectConfig, projectDir string) (*addResult, error) {
+	baseDir, err := os.MkdirTemp("", "lemmego-add-base-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(baseDir)
+	newDir, err := os.MkdirTemp("", "lemmego-add-new-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(newDir)
+
+	for dir, cfg := range map[string]ProjectConfig{baseDir: oldCfg, newDir: newCfg} {
+		if err := renderScaffold(src, origin, cfg, dir); err != nil {
+			return nil, err
+		}
+		if err := replaceModuleName(cfg.ModuleName, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &addResult{}
+	err = filepath.WalkDir(newDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(newDir, p)
+		if err != nil {
+			return err
+		}
+		if rel == projectManifestFile {
+			return nil
+		}
+
+		theirs, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		base, baseErr := os.ReadFile(filepath.Join(baseDir, rel))
+		if baseErr == nil && bytes.Equal(base, theirs) {
+			return nil
+		}
+
+		target := filepath.Join(projectDir, rel)
+		ours, oursErr := os.ReadFile(target)
+		switch {
+		case errors.Is(oursErr, fs.ErrNotExist) && baseErr == nil:
+			result.Skipped = append(result.Skipped, rel)
+			return nil
+		case errors.Is(oursErr, fs.ErrNotExist):
+			result.Created = append(result.Created, rel)
+			return writeProjectFile(target, theirs)
+		case oursErr != nil:
+			return oursErr
+		case bytes.Equal(ours, theirs):
+			return nil
+		case baseErr == nil && bytes.Equal(ours, base):
+			result.Updated = append(result.Updated, rel)
+			return writeProjectFile(target, theirs)
+		}
+
+		// The file is new to the scaffold but the project has its own,
+		// or both sides changed it.
+		merged, ok := merge3(string(base), string(ours), string(theirs), "scaffold")
+		if ok {
+			result.Merged = append(result.Merged, rel)
+			return writeProjectFile(target, []byte(merged))
+		}
+		result.Conflicts = append(result.Conflicts, rel)
+		return writeProjectFile(target+conflictSuffix, []byte(merged))
+	})
+	return result, err
+}
+
+func writeProjectFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// projectScaffold returns the scaffold the project was created from, falling
+// back to the current scaffold when it is no longer available.
+func projectScaffold(origin ScaffoldOrigin) (scaffoldSource, ScaffoldOrigin, func(), error) {
+	noop := func() {}
+	switch origin.Source {
+	case "template":
+		src, cleanup, err := openScaffoldTemplate(origin.Path)
+		return src, origin, cleanup, err
+	case "cache":
+		if origin.Ref != scaffoldRepoBranch {
+			src, current, err := resolveScaffold(origin.Ref)
+			return src, current, noop, err
+		}
+		if src, current, err := cachedScaffold(origin.Ref); err == nil && current.Version == origin.Version {
+			return src, current, noop, nil
+		}
+	case "embedded":
+		if src, current := embeddedScaffold(); current.Version == origin.Version {
+			return src, current, noop, nil
+		}
+	}
+
+	fmt.Printf("Warning: the %s the project was created from is no longer available, merging against the current scaffold\n", origin)
+	src, current, err := resolveScaffold("")
+	return src, current, noop, err
+}
+
+func printAddResult(result *addResult) {
+	for _, group := range []struct {
+		label string
+		files []string
+	}{
+		{"created", result.Created},
+		{"updated", result.Updated},
+		{"merged", result.Merged},
+		{"skipped, deleted in the project", result.Skipped},
+	} {
+		for _, file := range group.files {
+			fmt.Printf("  %s (%s)\n", file, group.label)
+		}
+	}
+
+	if len(result.Conflicts) > 0 {
+		fmt.Println("\nThese files have conflicting changes and were left as they are:")
+		for _, file := range result.Conflicts {
+			fmt.Printf("  %s, see %s\n", file, file+conflictSuffix)
+		}
+	}
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add <feature>",
+	Short: "Add a feature to an existing project",
+	Long: `Add auth, redis, queue, inertia-react, inertia-vue or templ to the project in the current directory, or set any
+option of the scaffold manifest with name=value. The overlay of the feature is applied and the affected stubs are
+re-rendered. Files you have modified get a three-way merge; merges with conflicts are written next to the file with a
+` + conflictSuffix + ` suffix instead of overwriting it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isLemmegoProject() {
+			fmt.Println("Error: This does not appear to be a Lemmego project directory.")
+			return
+		}
+		pm, err := loadProjectManifest()
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("Error: %s not found, lemmego add needs a project created with a version of lemmego new that writes it.\n", projectManifestFile)
+			return
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		moduleName, err := GetModuleName()
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		src, origin, cleanup, err := projectScaffold(pm.Scaffold)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		defer cleanup()
+		m, err := loadScaffoldManifest(src)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		dir, _ := os.Getwd()
+		oldCfg := pm.Config()
+		oldCfg.Name = filepath.Base(dir)
+		oldCfg.ModuleName = moduleName
+		oldCfg.Options = m.withOptionalDefaults(oldCfg.values())
+
+		values := oldCfg.values()
+		newValues, err := featureValues(m, args[0], values)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if maps.Equal(values, newValues) {
+			fmt.Printf("%s is already enabled.\n", args[0])
+			return
+		}
+		newCfg := newProjectConfig(oldCfg.Name, m.Complete(newValues, true))
+		if err := newCfg.Validate(m); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		fmt.Printf("> Adding %s...\n", args[0])
+		result, err := applyScaffoldChange(src, origin, oldCfg, newCfg, ".")
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		printAddResult(result)
+
+		newPM := newProjectManifest(newCfg, pm.Scaffold)
+		if err := writeProjectManifest(".", newPM); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		fmt.Println("\nNext steps:")
+		fmt.Println("  go mod tidy")
+		if hasNodeDeps(newCfg) {
+			fmt.Println("  pnpm install")
+		}
+		if hasTemplGenerate(newCfg) {
+			fmt.Println("  templ generate")
+		}
+		fmt.Println("  Copy any new settings from .env.example into .env")
+	},
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFeatureValues(t *testing.T) {
+	m := testManifest(t)
+	values := ProjectConfig{Preset: PresetMVC, ORM: OrmGORM, Frontend: FrontendTempl}.values()
+
+	got, err := featureValues(m, "inertia-react", values)
+	if err != nil || got["frontend"] != string(FrontendTemplInertiaReact) {
+		t.Errorf("inertia-react: frontend = %q, err = %v", got["frontend"], err)
+	}
+	if got, err := featureValues(m, "auth", values); err != nil || got["auth"] != "true" {
+		t.Errorf("auth: auth = %q, err = %v", got["auth"], err)
+	}
+	values["queue"] = "false"
+	if got, err := featureValues(m, "queue", values); err != nil || got["queue"] != "true" {
+		t.Errorf("queue: queue = %q, err = %v", got["queue"], err)
+	}
+	if _, err := featureValues(m, "mailer", values); err == nil {
+		t.Error("expected an error for a feature the scaffold does not declare")
+	}
+
+	values["preset"], values["frontend"] = string(PresetRESTAPI), ""
+	if _, err := featureValues(m, "templ", values); err == nil {
+		t.Error("expected templ to need the mvc preset")
+	}
+}
+
+func TestApplyScaffoldChange(t *testing.T) {
+	src, origin := embeddedScaffold()
+	oldCfg := ProjectConfig{Name: "app", ModuleName: "github.com/acme/app", Preset: PresetRESTAPI, ORM: OrmGORM}
+	newCfg := oldCfg
+	newCfg.EnableAuth = true
+
+	dir := t.TempDir()
+	if err := renderScaffold(src, origin, oldCfg, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceModuleName(oldCfg.ModuleName, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	providers := filepath.Join(dir, "bootstrap", "providers.go")
+	data, err := os.ReadFile(providers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(providers, append([]byte("// Edited by hand.\n"), data...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	user := filepath.Join(dir, "internal", "models", "user.go")
+	if err := os.WriteFile(user, []byte("package models\n\ntype User struct{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := applyScaffoldChange(src, origin, oldCfg, newCfg, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(result.Created, filepath.Join("internal", "inputs", "login_input.go")) {
+		t.Errorf("expected the auth overlay to be applied, got %+v", result)
+	}
+	if !slices.Contains(result.Merged, filepath.Join("bootstrap", "providers.go")) {
+		t.Errorf("expected providers.go to be merged, got %+v", result)
+	}
+	merged, err := os.ReadFile(providers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(merged), "// Edited by hand.\n") || !strings.Contains(string(merged), "github.com/lemmego/auth") {
+		t.Errorf("expected both changes in providers.go, got:\n%s", merged)
+	}
+
+	if !slices.Contains(result.Conflicts, filepath.Join("internal", "models", "user.go")) {
+		t.Errorf("expected user.go to conflict, got %+v", result)
+	}
+	if data, _ := os.ReadFile(user); string(data) != "package models\n\ntype User struct{}\n" {
+		t.Error("a conflicting file must not be overwritten")
+	}
+	if _, err := os.Stat(user + conflictSuffix); err != nil {
+		t.Error("expected the conflicting merge to be written next to the file")
+	}
+}
+
+func TestApplyScaffoldChangeQueue(t *testing.T) {
+	src, origin := embeddedScaffold()
+	oldCfg := ProjectConfig{Name: "app", ModuleName: "github.com/acme/app", Preset: PresetRESTAPI, ORM: OrmGORM, Options: map[string]string{"queue": "false"}}
+	newCfg := oldCfg
+	newCfg.Options = map[string]string{"queue": "true"}
+
+	dir := t.TempDir()
+	if err := renderScaffold(src, origin, oldCfg, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceModuleName(oldCfg.ModuleName, dir); err != nil {
+		t.Fatal(err)
+	}
+	providers := filepath.Join(dir, "bootstrap", "providers.go")
+	if data, err := os.ReadFile(providers); err != nil || strings.Contains(string(data), "queue") {
+		t.Fatalf("expected a project without the queue, got:\n%s (%v)", data, err)
+	}
+
+	result, err := applyScaffoldChange(src, origin, oldCfg, newCfg, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"go.mod", filepath.Join("bootstrap", "providers.go")} {
+		if !slices.Contains(result.Updated, file) {
+			t.Errorf("expected %s to be updated, got %+v", file, result)
+		}
+	}
+	for file, want := range map[string]string{
+		providers:                    "&queue.Provider{},",
+		filepath.Join(dir, "go.mod"): "github.com/lemmego/queue ",
+	} {
+		if data, _ := os.ReadFile(file); !strings.Contains(string(data), want) {
+			t.Errorf("expected %s to contain %q, got:\n%s", file, want, data)
+		}
+	}
+}
+
+func TestRenderScaffoldDefaultsOptionalOptions(t *testing.T) {
+	src, origin := embeddedScaffold()
+	// A config recorded before the queue option existed.
+	cfg := ProjectConfig{Name: "app", ModuleName: "github.com/acme/app", Preset: PresetRESTAPI, ORM: OrmGORM}
+
+	dir := t.TempDir()
+	if err := renderScaffold(src, origin, cfg, dir); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "bootstrap", "providers.go")); err != nil || !strings.Contains(string(data), "&queue.Provider{},") {
+		t.Errorf("expected the queue provider by default, got:\n%s (%v)", data, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, projectManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"queue": "true"`) {
+		t.Errorf("expected %s to record the queue default, got:\n%s", projectManifestFile, data)
+	}
+}
@@ -15,19 +15,22 @@ var buildCmd = &cobra.Command{
 			return
 		}
 
-		if hasTemplFiles() {
+		pm := currentProjectManifest()
+		usesTempl, hasNodeDeps := projectUsesTempl(pm), projectHasNodeDeps(pm)
+
+		if usesTempl {
 			fmt.Println("> Generating templ files...")
 			EnsureBinary("templ")
 			RunCommand(".", "templ", "generate")
 		}
 
-		if fileExists("package.json") {
+		if hasNodeDeps {
 			EnsureBinary("node")
 			fmt.Println("> Building frontend assets...")
 			RunCommand(".", npmBinary(), "run", "build")
 		}
 
-		if !hasTemplFiles() && !fileExists("package.json") {
+		if !usesTempl && !hasNodeDeps {
 			fmt.Println("Nothing to build (no templ files or Node dependencies found).")
 		}
 	},
@@ -1,16 +1,104 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cachePullCmd)
+	cacheCmd.AddCommand(cacheVerifyCmd)
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cached scaffold versions",
+	Long:  `Manage the scaffold versions cached at ~/.cache/lemmego/scaffold. The latest scaffold is cached as main, pinned versions under their tag.`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached scaffold versions",
+	Run: func(cmd *cobra.Command, args []string) {
+		refs := cachedScaffoldRefs()
+		if len(refs) == 0 {
+			fmt.Println("No scaffold is cached.")
+			return
+		}
+		for _, ref := range refs {
+			_, origin, err := cachedScaffold(ref)
+			if err != nil {
+				fmt.Printf("%-12s %v\n", ref, err)
+				continue
+			}
+			fmt.Printf("%-12s version %-10s %s\n", ref, origin.Version, origin.Path)
+		}
+	},
+}
+
+var cachePullCmd = &cobra.Command{
+	Use:   "pull [version]",
+	Short: "Fetch a scaffold version into the cache",
+	Long:  `Fetch and verify a tagged scaffold version, or the latest scaffold without one, so that it can be used with --offline.`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ref := scaffoldRepoBranch
+		if len(args) > 0 {
+			ref = args[0]
+		}
+
+		fmt.Printf("> Fetching scaffold %s...\n", ref)
+		if err := fetchScaffold(ref); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		_, origin, err := cachedScaffold(ref)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Cached the %s\n", origin)
+	},
+}
+
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify [version...]",
+	Short: "Verify cached scaffolds against their checksums",
+	Long:  `Verify the given cached scaffold versions, or all of them, against their SHA256SUMS. Exits with status 1 if any of them fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		refs := args
+		if len(refs) == 0 {
+			refs = cachedScaffoldRefs()
+		}
+		if len(refs) == 0 {
+			fmt.Println("No scaffold is cached.")
+			return
+		}
+
+		failed := false
+		for _, ref := range refs {
+			if _, _, err := cachedScaffold(ref); err != nil {
+				fmt.Printf("FAIL %s: %v\n", ref, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("ok   %s\n", ref)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
 var cacheCleanCmd = &cobra.Command{
-	Use:   "cache-clean",
+	Use:   "cache-clean [version...]",
 	Short: "Clear the local scaffold cache",
-	Long:  `Removes the cached scaffold templates at ~/.cache/lemmego/scaffold, forcing the CLI to use the embedded scaffold on the next project creation.`,
+	Long:  `Removes the given scaffold versions, or every cached scaffold, from ~/.cache/lemmego/scaffold, forcing the CLI to fetch or use the embedded scaffold on the next project creation.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cacheDir := scaffoldCache()
 		if cacheDir == "" {
@@ -23,6 +111,22 @@ var cacheCleanCmd = &cobra.Command{
 			return
 		}
 
+		for _, ref := range args {
+			dir := scaffoldCacheEntry(ref)
+			if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+				fmt.Printf("Scaffold %s is not cached.\n", ref)
+				continue
+			}
+			if err := os.RemoveAll(dir); err != nil {
+				fmt.Printf("Error removing scaffold %s: %v\n", ref, err)
+				continue
+			}
+			fmt.Printf("Removed scaffold %s from the cache\n", ref)
+		}
+		if len(args) > 0 {
+			return
+		}
+
 		if err := os.RemoveAll(cacheDir); err != nil {
 			fmt.Printf("Error clearing cache: %v\n", err)
 			return
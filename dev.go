@@ -29,6 +29,7 @@ var devCmd = &cobra.Command{
 			return
 		}
 
+		pm := currentProjectManifest()
 		var processes []devProcess
 
 		// Always: air for Go hot reload
@@ -36,13 +37,13 @@ var devCmd = &cobra.Command{
 		processes = append(processes, startDevProcess("air", ".", "air"))
 
 		// If templ files exist: templ generate --watch
-		if hasTemplFiles() {
+		if projectUsesTempl(pm) {
 			EnsureBinary("templ")
 			processes = append(processes, startDevProcess("templ", ".", "templ", "generate", "--watch", "--proxy", "http://localhost:8080"))
 		}
 
 		// If node deps exist: vite dev server
-		if fileExists("package.json") {
+		if projectHasNodeDeps(pm) {
 			processes = append(processes, startDevProcess("vite", ".", npmBinary(), "run", "dev"))
 		}
 
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FieldSpec is a field definition given on the command line in the form
+// name:type[:modifier...], e.g. "title:string:required". Modifiers may carry
+// a value, as in "password:string:min=8".
+type FieldSpec struct {
+	Name      string
+	Type      string
+	Modifiers []string
+}
+
+// ParseFieldSpec parses a single name:type[:modifier...] definition.
+func ParseFieldSpec(spec string) (*FieldSpec, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid field %q: expected name:type[:modifier...]", spec)
+	}
+	if err := SnakeCase(parts[0]); err != nil {
+		return nil, fmt.Errorf("invalid field %q: %w", spec, err)
+	}
+	for _, m := range parts[2:] {
+		if m == "" {
+			return nil, fmt.Errorf("invalid field %q: empty modifier", spec)
+		}
+	}
+	return &FieldSpec{Name: parts[0], Type: parts[1], Modifiers: parts[2:]}, nil
+}
+
+// ParseFieldSpecs parses every definition in specs, rejecting duplicate names.
+func ParseFieldSpecs(specs []string) ([]*FieldSpec, error) {
+	var fields []*FieldSpec
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		f, err := ParseFieldSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("field %q is defined more than once", f.Name)
+		}
+		seen[f.Name] = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// Has reports whether the modifier is present, with or without a value.
+func (fs *FieldSpec) Has(modifier string) bool {
+	_, ok := fs.Value(modifier)
+	return ok
+}
+
+// Value returns the value of a key=value modifier. A bare modifier yields an
+// empty value.
+func (fs *FieldSpec) Value(modifier string) (string, bool) {
+	for _, m := range fs.Modifiers {
+		key, value, _ := strings.Cut(m, "=")
+		if key == modifier {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// checkModifiers returns an error for the first modifier not in allowed.
+func (fs *FieldSpec) checkModifiers(allowed ...string) error {
+	for _, m := range fs.Modifiers {
+		key, _, _ := strings.Cut(m, "=")
+		if !slices.Contains(allowed, key) {
+			return fmt.Errorf("field %q: unknown modifier %q (expected one of: %s)", fs.Name, key, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
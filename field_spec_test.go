@@ -0,0 +1,164 @@
+package cli
+
+import "testing"
+
+func TestParseFieldSpec(t *testing.T) {
+	f, err := ParseFieldSpec("email:string:required:unique=users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "email" || f.Type != "string" {
+		t.Errorf("unexpected field %+v", f)
+	}
+	if !f.Has("required") || !f.Has("unique") || f.Has("primary") {
+		t.Errorf("unexpected modifiers %v", f.Modifiers)
+	}
+	if table, _ := f.Value("unique"); table != "users" {
+		t.Errorf("expected unique=users, got %q", table)
+	}
+
+	for _, spec := range []string{"title", "title:", "Title:string", "title:string::required"} {
+		if _, err := ParseFieldSpec(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestParseFieldSpecsDuplicate(t *testing.T) {
+	if _, err := ParseFieldSpecs([]string{"title:string", "title:text"}); err == nil {
+		t.Error("expected duplicate field names to be rejected")
+	}
+}
+
+func TestParseModelFields(t *testing.T) {
+	fields, err := ParseModelFields([]string{"title:string:required", "body:text", "author:relation:many_to_one"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 3 {
+		t.Fatalf("expected 3 fields, got %d", len(fields))
+	}
+	if !fields[0].Required || fields[0].Unique {
+		t.Errorf("unexpected title field %+v", fields[0])
+	}
+	if fields[1].Type != "string" {
+		t.Errorf("expected text to map to string, got %q", fields[1].Type)
+	}
+	if fields[2].Relation != RelationManyToOne {
+		t.Errorf("expected many_to_one relation, got %q", fields[2].Relation)
+	}
+
+	for _, specs := range [][]string{
+		{"author:relation"},
+		{"author:relation:sideways"},
+		{"title:string:indexed"},
+		{"id:uint64"},
+	} {
+		if _, err := ParseModelFields(specs); err == nil {
+			t.Errorf("expected %v to be rejected", specs)
+		}
+	}
+}
+
+func TestParseMigrationFields(t *testing.T) {
+	fields, err := ParseMigrationFields([]string{"user_id:unsignedBigInt:foreign", "slug:string:unique:nullable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fields[0].ForeignConstrained {
+		t.Error("expected user_id to be foreign constrained")
+	}
+	if !fields[1].Unique || !fields[1].Nullable {
+		t.Errorf("unexpected slug field %+v", fields[1])
+	}
+	fields, err = ParseMigrationFields([]string{"published_on:date"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields[0].Type != "dateTime" {
+		t.Errorf("expected date to map to dateTime, got %q", fields[0].Type)
+	}
+	if _, err := ParseMigrationFields([]string{"title:varchar"}); err == nil {
+		t.Error("expected unknown migration type to be rejected")
+	}
+}
+
+func TestParseInputFields(t *testing.T) {
+	fields, err := ParseInputFields([]string{"email:string:required:email:unique=users", "avatar:file", "team_id:uint:exists=teams.id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fields[0].Required || !fields[0].Email || !fields[0].Unique || fields[0].Table != "users" || fields[1].Type != "file" {
+		t.Errorf("unexpected email field %+v", fields[0])
+	}
+	if fields[2].Exists != "teams.id" {
+		t.Errorf("expected team_id to exist in teams.id, got %q", fields[2].Exists)
+	}
+	for _, specs := range [][]string{
+		{"email:string:unique"},
+		{"email:string:where=tenant_id=1"},
+		{"email:string:unique=users:where=tenant_id"},
+		{"team_id:uint:exists"},
+		{"age:uint:email"},
+		{"name:string:min=short"},
+		{"price:float64:max=1.5"},
+		{"age:uint:in=1,2"},
+		{"starts_at:date:before=tomorrow"},
+		{"email:string:regex=("},
+		{"email:string:primary"},
+	} {
+		if _, err := ParseInputFields(specs); err == nil {
+			t.Errorf("expected %v to be rejected", specs)
+		}
+	}
+}
+
+func TestParseFormFields(t *testing.T) {
+	fields, err := ParseFormFields([]string{"bio:textarea", "role:dropdown:admin,editor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields[1].Choices) != 2 || fields[1].Choices[1] != "editor" {
+		t.Errorf("unexpected choices %v", fields[1].Choices)
+	}
+	for _, specs := range [][]string{
+		{"role:dropdown"},
+		{"bio:textarea:long"},
+		{"bio:richtext"},
+	} {
+		if _, err := ParseFormFields(specs); err == nil {
+			t.Errorf("expected %v to be rejected", specs)
+		}
+	}
+}
+
+func TestParseResourceFields(t *testing.T) {
+	fields, err := ParseResourceFields([]string{"title:text:required:unique", "role:dropdown:required:admin,editor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fields[0].Required || !fields[0].Unique {
+		t.Errorf("unexpected title field %+v", fields[0])
+	}
+	if !fields[1].Required || len(fields[1].Choices) != 2 {
+		t.Errorf("unexpected role field %+v", fields[1])
+	}
+
+	rg := NewResourceGenerator(&ResourceConfig{Name: "post", Fields: fields})
+	if mf := rg.MigrationFields(); mf[1].Type != "string" || mf[1].Nullable {
+		t.Errorf("unexpected migration field %+v", mf[1])
+	}
+	if inf := rg.InputFields(); inf[0].Type != "string" || !inf[0].Required || !inf[0].Unique || inf[0].Table != "posts" {
+		t.Errorf("unexpected input field %+v", inf[0])
+	}
+
+	for _, specs := range [][]string{
+		{"title:string"},
+		{"title:text:indexed"},
+		{"role:radio"},
+	} {
+		if _, err := ParseResourceFields(specs); err == nil {
+			t.Errorf("expected %v to be rejected", specs)
+		}
+	}
+}
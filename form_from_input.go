@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// FormFieldsFromInput reads the input struct of internal/inputs/<name>_input.go
+// and returns a form field for each of its exported fields.
+func FormFieldsFromInput(name string) ([]*FormField, error) {
+	path := filepath.Join("internal", "inputs", name+"_input.go")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	strcase.ConfigureAcronym("id", "ID")
+	fields, err := parseInputFormFields(src, strcase.ToCamel(name)+"Input")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fields, nil
+}
+
+// parseInputFormFields returns the form fields of the struct typeName in src.
+// Fields are named after their form or json tag, their types come from
+// formTypeForGoType and they are required when the Validate method of the
+// struct calls Required() on them.
+func parseInputFormFields(src []byte, typeName string) ([]*FormField, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	required := map[string]bool{}
+	for _, decl := range file.Decls {
+		d, ok := decl.(*ast.FuncDecl)
+		if !ok || d.Name.Name != "Validate" || d.Body == nil || receiverTypeName(d) != typeName {
+			continue
+		}
+		ast.Inspect(d.Body, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if name, methods := validatorChain(call); name != "" && slices.Contains(methods, "Required") {
+					required[name] = true
+				}
+			}
+			return true
+		})
+	}
+
+	st := findStructType(file, typeName)
+	if st == nil {
+		return nil, fmt.Errorf("no %s struct found", typeName)
+	}
+
+	var fields []*FormField
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			value, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(value)
+		}
+		for _, ident := range f.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			name, ok := inputFieldName(ident.Name, tag)
+			if !ok {
+				continue
+			}
+			fields = append(fields, &FormField{
+				Name:     name,
+				Type:     formTypeForGoType(types.ExprString(f.Type)),
+				Required: required[name],
+			})
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%s has no exported fields", typeName)
+	}
+	return fields, nil
+}
+
+// findStructType returns the struct type declared as name in file, or nil.
+func findStructType(file *ast.File, name string) *ast.StructType {
+	for _, decl := range file.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range d.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == name {
+				st, _ := ts.Type.(*ast.StructType)
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// inputFieldName returns the name a field is submitted under: the first key
+// of its in:"form=..." tag, else its json name. Fields hidden from json
+// without a form tag are not part of the form.
+func inputFieldName(goName string, tag reflect.StructTag) (string, bool) {
+	for _, directive := range strings.Split(tag.Get("in"), ";") {
+		if keys, ok := strings.CutPrefix(strings.TrimSpace(directive), "form="); ok && keys != "" {
+			name, _, _ := strings.Cut(keys, ",")
+			return name, true
+		}
+	}
+	name, _, _ := strings.Cut(tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = strcase.ToSnake(goName)
+	}
+	return name, true
+}
+
+// formTypeForGoType maps the Go type of an input field back to a form field
+// type through UiDataTypeMap. Where several form types share a Go type, the
+// first of formFieldTypes wins, so strings become text and times dates.
+// Numeric types missing from the map are integers or decimals, and anything
+// else is a text field.
+func formTypeForGoType(goType string) string {
+	if strings.HasSuffix(goType, "httpin.File") {
+		return "file"
+	}
+	goType = strings.TrimPrefix(goType, "*")
+	for _, t := range formFieldTypes {
+		if UiDataTypeMap[t] == goType && t != "file" {
+			return t
+		}
+	}
+	switch {
+	case strings.HasPrefix(goType, "int"), strings.HasPrefix(goType, "uint"):
+		return "integer"
+	case strings.HasPrefix(goType, "float"):
+		return "decimal"
+	}
+	return "text"
+}
+
+// validatorChain unwinds a call chain such as
+// v.Field("email", i.Email).Required().Email() into the name given to Field
+// and the methods called on its result.
+func validatorChain(call *ast.CallExpr) (string, []string) {
+	var methods []string
+	for {
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return "", nil
+		}
+		if sel.Sel.Name == "Field" && len(call.Args) > 0 {
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return "", nil
+			}
+			name, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return "", nil
+			}
+			return name, methods
+		}
+		methods = append(methods, sel.Sel.Name)
+		if call, ok = sel.X.(*ast.CallExpr); !ok {
+			return "", nil
+		}
+	}
+}
+
+func receiverTypeName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
@@ -3,15 +3,18 @@ package cli
 import (
 	_ "embed"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/iancoleman/strcase"
-	"github.com/lemmego/fsys"
 	"github.com/spf13/cobra"
 )
 
 var flavor string
+var formRoute string
+var formFromInput string
 
 //go:embed templ_form.txt
 var templFormStub string
@@ -19,28 +22,70 @@ var templFormStub string
 //go:embed react_form.txt
 var reactFormStub string
 
+//go:embed vue_form.txt
+var vueFormStub string
+
+//go:embed gohtml_form.txt
+var gohtmlFormStub string
+
+var formFlavors = []string{"templ", "react", "vue", "gohtml"}
+
 var formFieldTypes = []string{"text", "textarea", "integer", "decimal", "boolean", "radio", "checkbox", "dropdown", "date", "time", "datetime", "file"}
 
 type FormField struct {
-	Name    string
-	Type    string
-	Choices []string
+	Name     string
+	Type     string
+	Choices  []string
+	Required bool
 }
 
 type FormConfig struct {
 	Name   string
-	Flavor string // templ, react
+	Flavor string // templ, react, vue, gohtml
 	Fields []*FormField
 	Route  string
 }
 
 type FormGenerator struct {
 	name   string
-	flavor string // templ, react
+	flavor string // templ, react, vue, gohtml
 	fields []*FormField
 	route  string
 }
 
+// ParseFormFields converts command-line field specs such as "bio:textarea"
+// or "role:dropdown:admin,editor" into form fields. Radio, checkbox and
+// dropdown fields take their choices as a comma separated modifier.
+func ParseFormFields(specs []string) ([]*FormField, error) {
+	parsed, err := ParseFieldSpecs(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []*FormField
+	for _, spec := range parsed {
+		if !slices.Contains(formFieldTypes, spec.Type) {
+			return nil, fmt.Errorf("field %q: unknown type %q (expected one of: %s)", spec.Name, spec.Type, strings.Join(formFieldTypes, ", "))
+		}
+
+		var choices []string
+		for _, m := range spec.Modifiers {
+			choices = append(choices, strings.Split(m, ",")...)
+		}
+
+		hasChoices := spec.Type == "radio" || spec.Type == "checkbox" || spec.Type == "dropdown"
+		if hasChoices && len(choices) == 0 {
+			return nil, fmt.Errorf("field %q: %s needs choices, e.g. %s:%s:one,two", spec.Name, spec.Type, spec.Name, spec.Type)
+		}
+		if !hasChoices && len(choices) > 0 {
+			return nil, fmt.Errorf("field %q: only radio, checkbox and dropdown fields take choices", spec.Name)
+		}
+
+		fields = append(fields, &FormField{Name: spec.Name, Type: spec.Type, Choices: choices})
+	}
+	return fields, nil
+}
+
 func NewFormGenerator(mc *FormConfig) *FormGenerator {
 	return &FormGenerator{mc.Name, mc.Flavor, mc.Fields, mc.Route}
 }
@@ -54,10 +99,10 @@ func (fg *FormGenerator) GetReplacables() []*Replacable {
 }
 
 func (fg *FormGenerator) GetPackagePath() string {
-	if fg.flavor == "react" {
+	if fg.flavor == "react" || fg.flavor == "vue" {
 		return "resources/js/Pages/Forms"
 	}
-	if fg.flavor == "templ" {
+	if fg.flavor == "templ" || fg.flavor == "gohtml" {
 		return "templates"
 	}
 	return ""
@@ -65,16 +110,25 @@ func (fg *FormGenerator) GetPackagePath() string {
 
 func (fg *FormGenerator) GetStub() string {
 	if fg.flavor == "react" {
-		return reactFormStub
+		return loadStub("react_form.txt")
+	}
+	if fg.flavor == "vue" {
+		return loadStub("vue_form.txt")
+	}
+	if fg.flavor == "gohtml" {
+		return loadStub("gohtml_form.txt")
 	}
 	if fg.flavor == "templ" {
-		return templFormStub
+		return loadStub("templ_form.txt")
 	}
 	return ""
 }
 
-func (fg *FormGenerator) Generate(appendable ...[]byte) error {
-	fs := fsys.NewLocalStorage("")
+func (fg *FormGenerator) Generate() error {
+	if !slices.Contains(formFlavors, fg.flavor) {
+		return fmt.Errorf("unknown form flavor %q (expected one of: %s)", fg.flavor, strings.Join(formFlavors, ", "))
+	}
+
 	parts := strings.Split(fg.GetPackagePath(), "/")
 	packageName := fg.GetPackagePath()
 
@@ -86,10 +140,6 @@ func (fg *FormGenerator) Generate(appendable ...[]byte) error {
 		"PackageName": packageName,
 	}
 
-	if len(appendable) > 0 {
-		tmplData["Appendable"] = string(appendable[0])
-	}
-
 	for _, v := range fg.GetReplacables() {
 		tmplData[v.Placeholder] = v.Value
 	}
@@ -100,50 +150,57 @@ func (fg *FormGenerator) Generate(appendable ...[]byte) error {
 		return err
 	}
 
-	if fg.flavor == "templ" {
-		err = fs.Write(fg.GetPackagePath()+"/"+fg.name+".templ", []byte(output))
-	} else if fg.flavor == "react" {
-		// Check if resources/js/Pages/Forms directory exists,
-		// create it if it doesn't
-		if exists, _ := fs.Exists(fg.GetPackagePath()); !exists {
-			err = fs.CreateDirectory(fg.GetPackagePath())
-			if err != nil {
-				return err
-			}
-		}
-		err = fs.Write(fg.GetPackagePath()+"/"+strcase.ToCamel(fg.name)+".tsx", []byte(output))
-	}
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return writeGeneratedFile(filepath.ToSlash(resourceViewFiles(fg.flavor, fg.name).Form), []byte(output))
 }
 
 func init() {
-	formCmd.Flags().StringVarP(&flavor, "flavor", "f", "react", "Which flavor do you want? (templ, react)")
+	formCmd.Flags().StringVarP(&flavor, "flavor", "f", "", "Which flavor do you want? (templ, react, vue, gohtml); detected from the project when omitted")
+	formCmd.Flags().StringVar(&formRoute, "route", "", "The route where the form should be submitted (e.g. /login)")
+	formCmd.Flags().StringVar(&formFromInput, "from-input", "", "Take the fields from internal/inputs/<name>_input.go")
 }
 
 var formCmd = &cobra.Command{
-	Use:   "form",
+	Use:   "form [name] [field:type[:choices]...]",
 	Short: "Generate a form template/view",
 	Long:  `Generate a form template/view`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var templName, route string
 		var fields []*FormField
-		if !shouldRunInteractively && len(args) == 0 {
+		if !shouldRunInteractively && len(args) == 0 && formFromInput == "" {
 			fmt.Println("Please provide a form name")
 			return
 		}
 
-		if shouldRunInteractively && len(args) == 0 {
+		if flavor == "" {
+			flavor = detectFormFlavor(currentProjectManifest())
+		}
+		if flavor == "" {
+			flavor = "react"
+		}
+
+		if formFromInput != "" {
+			if len(args) > 1 {
+				fmt.Println("Error: fields cannot be given together with --from-input")
+				return
+			}
+			templName = formFromInput
+			if len(args) > 0 {
+				templName = args[0]
+			}
+			route = formRoute
+			var err error
+			fields, err = FormFieldsFromInput(formFromInput)
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+		} else if shouldRunInteractively && len(args) == 0 {
 
 			nameForm := huh.NewForm(
 				huh.NewGroup(
 					huh.NewSelect[string]().
 						Title("Which flavor do you want?").
-						Options(huh.NewOptions("templ", "react")...).
+						Options(huh.NewOptions(formFlavors...)...).
 						Value(&flavor),
 					huh.NewInput().
 						Title("Enter the resource name in snake_case").
@@ -227,6 +284,13 @@ var formCmd = &cobra.Command{
 			}
 		} else {
 			templName = args[0]
+			route = formRoute
+			var err error
+			fields, err = ParseFormFields(args[1:])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
 		}
 
 		fg := NewFormGenerator(&FormConfig{Name: templName, Flavor: flavor, Fields: fields, Route: route})
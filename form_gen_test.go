@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormGenerateVue(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	fields, err := ParseFormFields([]string{"title:text", "agree:boolean", "tags:checkbox:go,vue", "cover:file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fg := NewFormGenerator(&FormConfig{Name: "blog_post", Flavor: "vue", Fields: fields, Route: "/posts"})
+	if err := fg.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.ReadFile(filepath.Join("resources", "js", "Pages", "Forms", "BlogPost.vue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<script setup>",
+		`import { useForm, usePage } from "@inertiajs/vue3";`,
+		"  \"title\": \"\",\n  \"agree\": false,\n  \"tags\": [],\n  \"cover\": null,\n",
+		`<form @submit.prevent="form.post('/posts')">`,
+		`<input id="title" name="title" type="text" class="input" v-model="form.title" />`,
+		`<input id="tags_vue" name="tags" type="checkbox" value="vue" v-model="form.tags" />`,
+		`@input="form.cover = $event.target.files[0]"`,
+		`<p v-if="errors.title" class="text-xs text-red-500" v-text="[].concat(errors.title).join(', ')"></p>`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected generated form to contain:\n%s\ngot:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormGenerateUnknownFlavor(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	fg := NewFormGenerator(&FormConfig{Name: "post", Flavor: "svelte"})
+	if err := fg.Generate(); err == nil {
+		t.Error("expected an unknown flavor to be rejected")
+	}
+}
+
+func TestFormGenerateGohtml(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	fields, err := ParseFormFields([]string{"title:text", "agree:boolean", "role:dropdown:admin,editor", "tags:checkbox:go,vue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fg := NewFormGenerator(&FormConfig{Name: "post", Flavor: "gohtml", Fields: fields, Route: "/posts"})
+	if err := fg.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := os.ReadFile(filepath.Join("templates", "post.page.gohtml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout, err := fs.ReadFile(scaffoldEmbedFS, "_scaffold/base/templates/base.layout.gohtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := template.New("post.page.gohtml").Parse(string(page))
+	if err != nil {
+		t.Fatalf("generated page does not parse: %v\n%s", err, page)
+	}
+	if _, err := tmpl.New("base.layout.gohtml").Parse(string(layout)); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	err = tmpl.Execute(&out, map[string]any{
+		"_token": "secret",
+		"input":  map[string]any{"title": "Hello", "agree": "true", "role": "editor", "tags": []string{"vue"}},
+		"errors": map[string][]string{"title": {"The title is too short"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<link rel="stylesheet" href="/static/css/dist.css">`,
+		`<form action="/posts" method="POST" enctype="multipart/form-data">`,
+		`<input type="hidden" name="_token" value="secret"/>`,
+		`<input type="hidden" name="_method" value="POST"/>`,
+		`<input id="title" name="title" type="text" class="input" value="Hello"/>`,
+		`<input id="agree" name="agree" type="checkbox" value="true" class="mr-2" checked/>`,
+		`<option value="editor" selected>editor</option>`,
+		`<option value="admin">admin</option>`,
+		`<input id="tags_vue" name="tags" type="checkbox" value="vue" checked/>`,
+		`<input id="tags_go" name="tags" type="checkbox" value="go"/>`,
+		`<p class="text-xs text-red-500">The title is too short</p>`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected rendered form to contain:\n%s\ngot:\n%s", want, out.String())
+		}
+	}
+
+	// A first visit has neither old input nor errors.
+	out.Reset()
+	if err := tmpl.Execute(&out, map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "text-red-500") {
+		t.Errorf("expected no errors to be rendered, got:\n%s", out.String())
+	}
+}
+
+func TestFormFieldsFromInput(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	inputFields, err := ParseInputFields([]string{"title:string:required", "views:integer", "published_at:date", "cover:file:required", "price:float64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewInputGenerator(&InputConfig{Name: "post", Fields: inputFields}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err := FormFieldsFromInput("post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FormField{
+		{Name: "title", Type: "text", Required: true},
+		{Name: "views", Type: "integer"},
+		{Name: "published_at", Type: "date"},
+		{Name: "cover", Type: "file", Required: true},
+		{Name: "price", Type: "decimal"},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %d fields, got %d", len(want), len(fields))
+	}
+	for i, f := range fields {
+		if f.Name != want[i].Name || f.Type != want[i].Type || f.Required != want[i].Required {
+			t.Errorf("field %d = %+v, want %+v", i, *f, want[i])
+		}
+	}
+}
+
+func TestParseInputFormFieldsJSONTags(t *testing.T) {
+	src, err := fs.ReadFile(scaffoldEmbedFS, "_scaffold/overlays/auth_gorm/internal/inputs/register_input.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := parseInputFormFields(src, "RegisterInput")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range fields {
+		if !f.Required || f.Type != "text" {
+			t.Errorf("expected a required text field, got %+v", *f)
+		}
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, ","); got != "name,email,password,password_confirmation" {
+		t.Errorf("unexpected fields %s", got)
+	}
+
+	if _, err := parseInputFormFields(src, "LoginInput"); err == nil {
+		t.Error("expected a missing struct to be reported")
+	}
+}
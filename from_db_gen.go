@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/spf13/cobra"
+)
+
+// migrationGoTypes maps the migration column types to the Go types of the
+// model fields holding them.
+var migrationGoTypes = map[string]string{
+	"increments":     "uint",
+	"bigIncrements":  "uint64",
+	"int":            "int",
+	"bigInt":         "int64",
+	"unsignedInt":    "uint",
+	"unsignedBigInt": "uint64",
+	"string":         "string",
+	"text":           "string",
+	"boolean":        "bool",
+	"decimal":        "float64",
+	"dateTime":       "time.Time",
+	"date":           "time.Time",
+	"time":           "time.Time",
+}
+
+// FromDBGenerator generates a migration and a model for every table of an
+// existing database.
+type FromDBGenerator struct {
+	tables     []*DBTable
+	orm        OrmChoice
+	models     bool
+	migrations bool
+}
+
+func NewFromDBGenerator(tables []*DBTable, orm OrmChoice) *FromDBGenerator {
+	return &FromDBGenerator{tables, orm, true, true}
+}
+
+// migrationColumnType maps the database type of c to the column type of the
+// migration package, one of migrationFieldTypes or date. Unknown types become
+// text.
+func migrationColumnType(c *DBColumn) string {
+	base, _, _ := strings.Cut(c.Type, "(")
+	base = strings.TrimSpace(base)
+	unsigned := strings.Contains(c.Type, "unsigned")
+	big := strings.HasPrefix(base, "big")
+
+	switch {
+	case c.Type == "tinyint(1)" || strings.HasPrefix(base, "bool"):
+		return "boolean"
+	case c.AutoIncrement && big:
+		return "bigIncrements"
+	case c.AutoIncrement:
+		return "increments"
+	case strings.Contains(base, "int"):
+		switch {
+		case big && unsigned:
+			return "unsignedBigInt"
+		case big:
+			return "bigInt"
+		case unsigned:
+			return "unsignedInt"
+		}
+		return "int"
+	case strings.Contains(base, "char") || base == "uuid" || base == "enum" || base == "set":
+		return "string"
+	case base == "decimal" || base == "numeric" || base == "real" || strings.HasPrefix(base, "double") || base == "float":
+		return "decimal"
+	case strings.HasPrefix(base, "datetime") || strings.HasPrefix(base, "timestamp"):
+		return "dateTime"
+	case base == "date":
+		return "date"
+	case strings.HasPrefix(base, "time"):
+		return "time"
+	}
+	return "text"
+}
+
+// uniqueColumn reports whether t has a unique key on column alone.
+func (t *DBTable) uniqueColumn(column string) bool {
+	return slices.ContainsFunc(t.Indexes, func(i *DBIndex) bool {
+		return i.Unique && len(i.Columns) == 1 && i.Columns[0] == column
+	})
+}
+
+func (t *DBTable) foreignKey(column string) *DBForeignKey {
+	for _, fk := range t.ForeignKeys {
+		if fk.Column == column {
+			return fk
+		}
+	}
+	return nil
+}
+
+func (t *DBTable) primaryColumns() []string {
+	var columns []string
+	for _, c := range t.Columns {
+		if c.Primary {
+			columns = append(columns, c.Name)
+		}
+	}
+	return columns
+}
+
+// tableMigrationConfig describes the migration creating t.
+func tableMigrationConfig(t *DBTable) *MigrationConfig {
+	mc := &MigrationConfig{TableName: t.Name}
+
+	primaries := t.primaryColumns()
+	if len(primaries) > 1 {
+		mc.PrimaryColumns = primaries
+	}
+
+	for _, c := range t.Columns {
+		field := &MigrationField{
+			Name:     c.Name,
+			Type:     migrationColumnType(c),
+			Length:   c.Length,
+			Nullable: c.Nullable,
+			Unique:   t.uniqueColumn(c.Name),
+			Primary:  c.Primary && len(primaries) == 1 && !c.AutoIncrement,
+		}
+		if fk := t.foreignKey(c.Name); fk != nil {
+			field.ForeignConstrained = true
+			field.OnDelete = fk.OnDelete
+			// Constrained() guesses the table from the column name.
+			if fk.RefColumn != "id" || guessPluralizedTableNameFromColumnName(c.Name) != fk.RefTable {
+				field.ForeignTable = fk.RefTable
+			}
+			if fk.RefColumn != "id" {
+				field.ForeignColumn = fk.RefColumn
+			}
+		}
+		mc.Fields = append(mc.Fields, field)
+	}
+
+	for _, i := range t.Indexes {
+		switch {
+		case i.Unique && len(i.Columns) > 1:
+			mc.UniqueColumns = append(mc.UniqueColumns, i.Columns)
+		case !i.Unique:
+			mc.Indexes = append(mc.Indexes, i.Columns)
+		}
+	}
+	return mc
+}
+
+// tableModelConfig describes the model of t. Foreign keys named <name>_id
+// become many_to_one relations.
+func tableModelConfig(t *DBTable, orm OrmChoice) *ModelConfig {
+	p := pluralize.NewClient()
+	mc := &ModelConfig{Name: p.Singular(t.Name), ORM: orm}
+
+	for _, c := range t.Columns {
+		unique := t.uniqueColumn(c.Name)
+		if fk := t.foreignKey(c.Name); fk != nil && strings.HasSuffix(c.Name, "_id") {
+			mc.Fields = append(mc.Fields, &ModelField{
+				Name:               strings.TrimSuffix(c.Name, "_id"),
+				Type:               "relation",
+				Relation:           RelationManyToOne,
+				RelatedModel:       p.Singular(fk.RefTable),
+				ForeignConstrained: fk.OnDelete == "CASCADE",
+				Required:           !c.Nullable,
+				Unique:             unique,
+				Primary:            c.Primary,
+			})
+			continue
+		}
+
+		mc.Fields = append(mc.Fields, &ModelField{
+			Name:     c.Name,
+			Type:     migrationGoTypes[migrationColumnType(c)],
+			Length:   c.Length,
+			Required: !c.Nullable,
+			Unique:   unique,
+			Primary:  c.Primary,
+		})
+	}
+	return mc
+}
+
+// orderByDependencies orders tables so that every table comes after the
+// tables its foreign keys reference, keeping the original order otherwise.
+// Tables in a reference cycle keep their original order.
+func orderByDependencies(tables []*DBTable) []*DBTable {
+	byName := map[string]*DBTable{}
+	for _, t := range tables {
+		byName[t.Name] = t
+	}
+
+	var ordered []*DBTable
+	state := map[string]int{} // 1 while visiting, 2 when done
+	var visit func(t *DBTable)
+	visit = func(t *DBTable) {
+		if state[t.Name] != 0 {
+			return
+		}
+		state[t.Name] = 1
+		for _, fk := range t.ForeignKeys {
+			if ref, ok := byName[fk.RefTable]; ok && ref != t {
+				visit(ref)
+			}
+		}
+		state[t.Name] = 2
+		ordered = append(ordered, t)
+	}
+	for _, t := range tables {
+		visit(t)
+	}
+	return ordered
+}
+
+func (g *FromDBGenerator) Generate() error {
+	if g.migrations {
+		for i, t := range orderByDependencies(g.tables) {
+			mg := NewMigrationGenerator(tableMigrationConfig(t))
+			// Keep the migrations in dependency order.
+			for range i {
+				mg.BumpVersion()
+			}
+			if err := mg.Generate(); err != nil {
+				return fmt.Errorf("generating migration for %s: %w", t.Name, err)
+			}
+			fmt.Printf("Migration for %s generated successfully.\n", t.Name)
+		}
+	}
+
+	if g.models {
+		for _, t := range g.tables {
+			if err := NewModelGenerator(tableModelConfig(t, g.orm)).Generate(); err != nil {
+				return fmt.Errorf("generating model for %s: %w", t.Name, err)
+			}
+			fmt.Printf("Model for %s generated successfully.\n", t.Name)
+		}
+	}
+	return nil
+}
+
+func (g *FromDBGenerator) Command() *cobra.Command {
+	return fromDBCmd
+}
+
+var fromDBOnly string
+
+func init() {
+	fromDBCmd.Flags().StringVar(&fromDBOnly, "only", "", "Generate only models or only migrations")
+}
+
+var fromDBCmd = &cobra.Command{
+	Use:   "from-db [table...]",
+	Short: "Generate models and migrations from an existing database",
+	Long: `Generate a model and a migration for every table of the database configured by the DB_* settings in .env,
+or for the given tables only. Columns, indexes and foreign keys are read from SQLite, MySQL and Postgres databases.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isLemmegoProject() {
+			fmt.Println("Error: This does not appear to be a Lemmego project directory.")
+			return
+		}
+		if fromDBOnly != "" && fromDBOnly != "models" && fromDBOnly != "migrations" {
+			fmt.Println("--only must be models or migrations")
+			return
+		}
+
+		db, dialect, err := openProjectDB()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer db.Close()
+
+		tables, err := inspectSchema(db, dialect, args)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(tables) == 0 {
+			fmt.Println("The database has no tables.")
+			return
+		}
+
+		g := NewFromDBGenerator(tables, detectProjectORM(currentProjectManifest()))
+		g.models = fromDBOnly != "migrations"
+		g.migrations = fromDBOnly != "models"
+		if err := g.Generate(); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
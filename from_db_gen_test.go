@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func createTestDatabase(t *testing.T, path string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email VARCHAR(255) NOT NULL UNIQUE, name TEXT)`,
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY, author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE, title VARCHAR(200) NOT NULL, published_on DATE)`,
+		`CREATE INDEX posts_title_published_on ON posts (title, published_on)`,
+		`CREATE TABLE schema_migrations (version TEXT)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInspectSQLiteSchema(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir("storage", 0755); err != nil {
+		t.Fatal(err)
+	}
+	createTestDatabase(t, filepath.Join("storage", "database.sqlite"))
+	if err := os.WriteFile(".env", []byte("DB_CONNECTION=sqlite\nDB_DATABASE=./storage/database.sqlite\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, dialect, err := openProjectDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tables, err := inspectSchema(db, dialect, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, table := range tables {
+		names = append(names, table.Name)
+	}
+	if !slices.Equal(names, []string{"posts", "users"}) {
+		t.Fatalf("unexpected tables %v", names)
+	}
+
+	posts := tables[0]
+	id := posts.Columns[0]
+	if id.Type != "bigint" || !id.Primary || !id.AutoIncrement {
+		t.Errorf("unexpected id column %+v", id)
+	}
+	if title := posts.Columns[2]; title.Type != "varchar(200)" || title.Length != 200 || title.Nullable {
+		t.Errorf("unexpected title column %+v", title)
+	}
+	if len(posts.Indexes) != 1 || !slices.Equal(posts.Indexes[0].Columns, []string{"title", "published_on"}) || posts.Indexes[0].Unique {
+		t.Errorf("unexpected indexes %+v", posts.Indexes)
+	}
+	if len(posts.ForeignKeys) != 1 || *posts.ForeignKeys[0] != (DBForeignKey{"author_id", "users", "id", "CASCADE"}) {
+		t.Errorf("unexpected foreign keys %+v", posts.ForeignKeys)
+	}
+
+	if !tables[1].uniqueColumn("email") {
+		t.Error("expected users.email to be unique")
+	}
+
+	if _, err := inspectSchema(db, dialect, []string{"comments"}); err == nil {
+		t.Error("expected an error for a missing table")
+	}
+}
+
+func TestMigrationColumnType(t *testing.T) {
+	tests := []struct {
+		column DBColumn
+		want   string
+	}{
+		{DBColumn{Type: "bigint", AutoIncrement: true}, "bigIncrements"},
+		{DBColumn{Type: "integer", AutoIncrement: true}, "increments"},
+		{DBColumn{Type: "bigint unsigned"}, "unsignedBigInt"},
+		{DBColumn{Type: "int(10) unsigned"}, "unsignedInt"},
+		{DBColumn{Type: "smallint"}, "int"},
+		{DBColumn{Type: "tinyint(1)"}, "boolean"},
+		{DBColumn{Type: "boolean"}, "boolean"},
+		{DBColumn{Type: "character varying"}, "string"},
+		{DBColumn{Type: "varchar(100)"}, "string"},
+		{DBColumn{Type: "numeric(8,2)"}, "decimal"},
+		{DBColumn{Type: "double precision"}, "decimal"},
+		{DBColumn{Type: "timestamp without time zone"}, "dateTime"},
+		{DBColumn{Type: "datetime"}, "dateTime"},
+		{DBColumn{Type: "date"}, "date"},
+		{DBColumn{Type: "time"}, "time"},
+		{DBColumn{Type: "jsonb"}, "text"},
+	}
+	for _, tt := range tests {
+		if got := migrationColumnType(&tt.column); got != tt.want {
+			t.Errorf("migrationColumnType(%q) = %s, want %s", tt.column.Type, got, tt.want)
+		}
+	}
+}
+
+func testPostsTable() *DBTable {
+	return &DBTable{
+		Name: "posts",
+		Columns: []*DBColumn{
+			{Name: "id", Type: "bigint", Primary: true, AutoIncrement: true},
+			{Name: "author_id", Type: "bigint"},
+			{Name: "slug", Type: "varchar(191)", Length: 191},
+			{Name: "body", Type: "text", Nullable: true},
+		},
+		Indexes: []*DBIndex{
+			{Name: "posts_slug_unique", Columns: []string{"slug"}, Unique: true},
+			{Name: "posts_author_id_slug", Columns: []string{"author_id", "slug"}},
+		},
+		ForeignKeys: []*DBForeignKey{{Column: "author_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"}},
+	}
+}
+
+func TestTableMigrationConfig(t *testing.T) {
+	mc := tableMigrationConfig(testPostsTable())
+
+	want := []MigrationField{
+		{Name: "id", Type: "bigIncrements"},
+		{Name: "author_id", Type: "bigInt", ForeignConstrained: true, ForeignTable: "users", OnDelete: "CASCADE"},
+		{Name: "slug", Type: "string", Length: 191, Unique: true},
+		{Name: "body", Type: "text", Nullable: true},
+	}
+	if len(mc.Fields) != len(want) {
+		t.Fatalf("expected %d fields, got %d", len(want), len(mc.Fields))
+	}
+	for i, w := range want {
+		if *mc.Fields[i] != w {
+			t.Errorf("field %d = %+v, want %+v", i, *mc.Fields[i], w)
+		}
+	}
+	if len(mc.Indexes) != 1 || !slices.Equal(mc.Indexes[0], []string{"author_id", "slug"}) {
+		t.Errorf("unexpected indexes %v", mc.Indexes)
+	}
+}
+
+func TestTableModelConfig(t *testing.T) {
+	mc := tableModelConfig(testPostsTable(), OrmGORM)
+	if mc.Name != "post" {
+		t.Errorf("unexpected model name %s", mc.Name)
+	}
+
+	author := mc.Fields[1]
+	if author.Name != "author" || author.Relation != RelationManyToOne || author.RelatedModel != "user" || !author.ForeignConstrained || !author.Required {
+		t.Errorf("unexpected author field %+v", author)
+	}
+	if slug := mc.Fields[2]; slug.Type != "string" || slug.Length != 191 {
+		t.Errorf("unexpected slug field %+v", slug)
+	}
+	if body := mc.Fields[3]; body.Type != "string" || body.Required {
+		t.Errorf("unexpected body field %+v", body)
+	}
+}
+
+func TestFromDBGenerateKeepsLengths(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+	writeOptions = WriteOptions{}
+
+	if err := NewFromDBGenerator([]*DBTable{testPostsTable()}, OrmGORM).Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := filepath.Glob(filepath.Join("internal", "migrations", "*_create_posts_table.go"))
+	if err != nil || len(migrations) != 1 {
+		t.Fatalf("expected the posts migration, got %v (%v)", migrations, err)
+	}
+	if migration := readGoFile(t, migrations[0]); !strings.Contains(migration, `t.String("slug", 191).Unique()`) {
+		t.Errorf("expected the slug column to keep its length, got:\n%s", migration)
+	}
+	if model := readGoFile(t, filepath.Join("internal", "models", "post.go")); !strings.Contains(model, `gorm:"size:191;uniqueIndex;not null"`) {
+		t.Errorf("expected the slug field to keep its length, got:\n%s", model)
+	}
+}
+
+func TestOrderByDependencies(t *testing.T) {
+	comments := &DBTable{Name: "comments", ForeignKeys: []*DBForeignKey{{Column: "post_id", RefTable: "posts"}}}
+	posts := &DBTable{Name: "posts", ForeignKeys: []*DBForeignKey{{Column: "author_id", RefTable: "users"}}}
+	users := &DBTable{Name: "users"}
+
+	var names []string
+	for _, table := range orderByDependencies([]*DBTable{comments, posts, users}) {
+		names = append(names, table.Name)
+	}
+	if !slices.Equal(names, []string{"users", "posts", "comments"}) {
+		t.Errorf("unexpected order %v", names)
+	}
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"html/template"
 	"log"
 	"reflect"
@@ -52,10 +53,7 @@ var CommonFuncs = template.FuncMap{
 		caser := cases.Title(language.English)
 		return caser.String(str)
 	},
-	"toCamel": func(str string) string {
-		strcase.ConfigureAcronym("id", "ID")
-		return strcase.ToCamel(str)
-	},
+	"toCamel": goName,
 	"toLowerCamel": func(str string) string {
 		strcase.ConfigureAcronym("id", "ID")
 		return strcase.ToLowerCamel(str)
@@ -69,11 +67,22 @@ var CommonFuncs = template.FuncMap{
 	"concat": func(str string, strs ...string) string {
 		return str + strings.Join(strs, "")
 	},
+	// action writes a Go template action into stubs that generate templates,
+	// e.g. {{action "index $.input %q" "title"}}. It is typed as an attribute
+	// so that it can also be used inside a tag.
+	"action": func(format string, args ...any) template.HTMLAttr {
+		return template.HTMLAttr("{{ " + fmt.Sprintf(format, args...) + " }}")
+	},
+	// attr writes a whole attribute whose value holds Go template actions,
+	// e.g. {{attr "href" "%s/{{ .ID }}" "/posts"}}. Actions written inside a
+	// URL attribute of the stub would be escaped.
+	"attr": func(name, format string, args ...any) template.HTMLAttr {
+		return template.HTMLAttr(name + `="` + fmt.Sprintf(format, args...) + `"`)
+	},
 }
 
 type Gen struct {
-	Name       string
-	Appendable []byte
+	Name string
 }
 
 type Replacable struct {
@@ -130,3 +139,14 @@ var genCmd = &cobra.Command{
 		log.Println("An argument must be provided to the gen command (e.g. model, input, migration, handlers, etc.)")
 	},
 }
+
+// goName converts a snake_case name into an exported Go identifier, writing
+// id as ID wherever it appears as a word, e.g. AuthorID for author_id.
+func goName(str string) string {
+	strcase.ConfigureAcronym("id", "ID")
+	parts := strings.Split(str, "_")
+	for i, part := range parts {
+		parts[i] = strcase.ToCamel(part)
+	}
+	return strings.Join(parts, "")
+}
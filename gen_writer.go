@@ -0,0 +1,344 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/scanner"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lemmego/fsys"
+	"golang.org/x/tools/imports"
+)
+
+// WriteOptions controls how generators write their output. They are shared by
+// every generator and set through the persistent flags of the gen command.
+type WriteOptions struct {
+	DryRun bool // Print the rendered files instead of writing them
+	Diff   bool // Print a unified diff against the files on disk instead of writing them
+	Force  bool // Overwrite files that already exist
+}
+
+var writeOptions WriteOptions
+
+// writeGeneratedFile writes a newly generated file. An existing Go file is
+// merged with it through mergeGoFile, so that what was added by hand is kept;
+// other existing files are only overwritten with --force. With --dry-run or
+// --diff nothing is written and the would-be result is printed instead.
+func writeGeneratedFile(filePath string, content []byte) error {
+	if !writeOptions.Force && strings.HasSuffix(filePath, ".go") {
+		if existing, err := os.ReadFile(filePath); err == nil {
+			merged, err := mergeGoFile(existing, content)
+			if err != nil {
+				return fmt.Errorf("%s already exists and can't be merged, use --force to overwrite it: %w", filePath, err)
+			}
+			if bytes.Equal(merged, existing) && !writeOptions.Diff {
+				fmt.Printf("%s is up to date\n", filePath)
+				return nil
+			}
+			return writeFile(filePath, merged, true)
+		}
+	}
+	return writeFile(filePath, content, writeOptions.Force)
+}
+
+// writeGeneratedGoFile formats content rendered from stub with gofmt and
+// goimports, which also drops the imports it doesn't use, and writes it with
+// writeGeneratedFile. Output that isn't valid Go is reported with the stub and
+// the offending line instead of being written.
+func writeGeneratedGoFile(stub, filePath string, content []byte) error {
+	formatted, err := formatGoFile(stub, filePath, content)
+	if err != nil {
+		return err
+	}
+	return writeGeneratedFile(filePath, formatted)
+}
+
+// formatGoFile runs content rendered from stub through gofmt and goimports,
+// reporting output that isn't valid Go with invalidGoError.
+func formatGoFile(stub, filePath string, content []byte) ([]byte, error) {
+	formatted, err := imports.Process(filePath, content, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+	if err != nil {
+		return nil, invalidGoError(stub, filePath, content, err)
+	}
+	return formatted, nil
+}
+
+// invalidGoError describes a parse error in Go code rendered from stub,
+// quoting the rendered line it occurred on.
+func invalidGoError(stub, filePath string, content []byte, err error) error {
+	source := stub
+	if _, statErr := os.Stat(filepath.Join(projectStubsDir, stub)); statErr == nil {
+		source = filepath.ToSlash(filepath.Join(projectStubsDir, stub))
+	}
+
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return fmt.Errorf("stub %s renders invalid Go for %s: %w", source, filePath, err)
+	}
+	pos := list[0].Pos
+	msg := fmt.Sprintf("stub %s renders invalid Go for %s: line %d: %s", source, filePath, pos.Line, list[0].Msg)
+	if lines := strings.Split(string(content), "\n"); pos.Line > 0 && pos.Line <= len(lines) {
+		msg += fmt.Sprintf("\n%6d | %s", pos.Line, lines[pos.Line-1])
+	}
+	if len(list) > 1 {
+		msg += fmt.Sprintf("\n(and %d more errors)", len(list)-1)
+	}
+	return errors.New(msg)
+}
+
+// updateGeneratedFile writes content to a file the generator intentionally
+// edits in place, such as a routes file, honouring --dry-run and --diff.
+func updateGeneratedFile(filePath string, content []byte) error {
+	return writeFile(filePath, content, true)
+}
+
+func writeFile(filePath string, content []byte, overwrite bool) error {
+	fs := fsys.NewLocalStorage("")
+	exists, err := fs.Exists(filePath)
+	if err != nil {
+		return err
+	}
+
+	var existing []byte
+	if exists {
+		existing, err = os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if writeOptions.Diff {
+		from := "a/" + filePath
+		if !exists {
+			from = "/dev/null"
+		}
+		diff := unifiedDiff(string(existing), string(content), from, "b/"+filePath)
+		if diff == "" {
+			fmt.Printf("No changes to %s\n", filePath)
+		} else {
+			fmt.Print(diff)
+		}
+		return nil
+	}
+
+	if writeOptions.DryRun {
+		action := "create"
+		if exists {
+			action = "overwrite"
+		}
+		fmt.Printf("Would %s %s:\n%s\n", action, filePath, content)
+		return nil
+	}
+
+	if exists && !overwrite {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", filePath)
+	}
+
+	if dir := path.Dir(filePath); dir != "." {
+		if err := fs.CreateDirectory(dir); err != nil {
+			return err
+		}
+	}
+
+	return fs.Write(filePath, content)
+}
+
+// unifiedDiff returns a unified diff with three lines of context between a
+// and b, or an empty string when they are equal.
+func unifiedDiff(a, b, fromName, toName string) string {
+	if a == b {
+		return ""
+	}
+
+	aLines := splitLines(a)
+	bLines := splitLines(b)
+	ops := diffLines(aLines, bLines)
+
+	const context = 3
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(ops); {
+		// Find the next change.
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		start := max(i-context, 0)
+		// Extend the hunk until there are more than 2*context unchanged lines.
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+
+		aStart, bStart := ops[start].aLine, ops[start].bLine
+		var aCount, bCount int
+		var body strings.Builder
+		for _, op := range ops[start:end] {
+			switch op.kind {
+			case ' ':
+				aCount++
+				bCount++
+			case '-':
+				aCount++
+			case '+':
+				bCount++
+			}
+			body.WriteByte(op.kind)
+			body.WriteString(op.text)
+			body.WriteByte('\n')
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		out.WriteString(body.String())
+		i = end
+	}
+
+	return out.String()
+}
+
+// hunkRange formats a 0-based start line and line count as a unified diff
+// range. Empty ranges point at the line before the hunk.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+type diffOp struct {
+	kind  byte // ' ', '-' or '+'
+	text  string
+	aLine int // 0-based line in a at which this op applies
+	bLine int // 0-based line in b at which this op applies
+}
+
+// diffLines computes a line diff of a and b based on their longest common
+// subsequence.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// merge3 merges the changes from base to ours and from base to theirs, line
+// by line. Regions changed differently on both sides are kept with conflict
+// markers, in which case ok is false.
+func merge3(base, ours, theirs, theirsName string) (merged string, ok bool) {
+	b, o, t := splitLines(base), splitLines(ours), splitLines(theirs)
+	oursMatch, theirsMatch := lineMatches(b, o), lineMatches(b, t)
+
+	var out []string
+	ok = true
+	i, oi, ti := 0, 0, 0
+	for {
+		// The next base line kept by both sides ends the current region.
+		k := i
+		for k < len(b) && (oursMatch[k] < 0 || theirsMatch[k] < 0) {
+			k++
+		}
+		oe, te := len(o), len(t)
+		if k < len(b) {
+			oe, te = oursMatch[k], theirsMatch[k]
+		}
+
+		baseChunk, oursChunk, theirsChunk := b[i:k], o[oi:oe], t[ti:te]
+		switch {
+		case slices.Equal(oursChunk, baseChunk):
+			out = append(out, theirsChunk...)
+		case slices.Equal(theirsChunk, baseChunk), slices.Equal(oursChunk, theirsChunk):
+			out = append(out, oursChunk...)
+		default:
+			ok = false
+			out = append(out, "<<<<<<< yours")
+			out = append(out, oursChunk...)
+			out = append(out, "=======")
+			out = append(out, theirsChunk...)
+			out = append(out, ">>>>>>> "+theirsName)
+		}
+
+		if k == len(b) {
+			break
+		}
+		out = append(out, b[k])
+		i, oi, ti = k+1, oe+1, te+1
+	}
+
+	if len(out) == 0 {
+		return "", ok
+	}
+	return strings.Join(out, "\n") + "\n", ok
+}
+
+// lineMatches returns, for every line of a, the index of the line of b it is
+// matched with in their longest common subsequence, or -1.
+func lineMatches(a, b []string) []int {
+	matches := make([]int, len(a))
+	for i := range matches {
+		matches[i] = -1
+	}
+	for _, op := range diffLines(a, b) {
+		if op.kind == ' ' {
+			matches[op.aLine] = op.bLine
+		}
+	}
+	return matches
+}
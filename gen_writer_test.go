@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "package models\n\ntype Post struct {\n\tID uint64\n}\n"
+	b := "package models\n\ntype Post struct {\n\tID    uint64\n\tTitle string\n}\n"
+
+	want := `--- a/post.go
++++ b/post.go
+@@ -1,5 +1,6 @@
+ package models
+ 
+ type Post struct {
+-	ID uint64
++	ID    uint64
++	Title string
+ }
+`
+	if got := unifiedDiff(a, b, "a/post.go", "b/post.go"); got != want {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+
+	if got := unifiedDiff(a, a, "a", "b"); got != "" {
+		t.Errorf("expected no diff for equal input, got:\n%s", got)
+	}
+}
+
+func TestUnifiedDiffNewFile(t *testing.T) {
+	got := unifiedDiff("", "one\ntwo\n", "/dev/null", "b/new.txt")
+	want := "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+one\n+two\n"
+	if got != want {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+}
+
+func TestUnifiedDiffSeparateHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 20; i++ {
+		a = append(a, "line")
+		b = append(b, "line")
+	}
+	a[1], b[1] = "old first", "new first"
+	a[18], b[18] = "old second", "new second"
+
+	got := unifiedDiff(strings.Join(a, "\n"), strings.Join(b, "\n"), "a", "b")
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Errorf("expected 2 hunks, got %d:\n%s", n, got)
+	}
+	if !strings.Contains(got, "@@ -1,5 +1,5 @@") || !strings.Contains(got, "@@ -16,5 +16,5 @@") {
+		t.Errorf("unexpected hunk ranges:\n%s", got)
+	}
+}
+
+func TestWriteGeneratedFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+
+	writeOptions = WriteOptions{}
+	if err := writeGeneratedFile("templates/post.templ", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeGeneratedFile("templates/post.templ", []byte("v2")); err == nil {
+		t.Error("expected an error when overwriting without --force")
+	}
+
+	writeOptions = WriteOptions{DryRun: true}
+	if err := writeGeneratedFile("templates/comment.templ", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("templates/comment.templ"); !os.IsNotExist(err) {
+		t.Error("expected --dry-run not to write the file")
+	}
+
+	writeOptions = WriteOptions{Diff: true}
+	if err := writeGeneratedFile("templates/post.templ", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+
+	writeOptions = WriteOptions{Force: true}
+	if err := writeGeneratedFile("templates/post.templ", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("templates/post.templ"); string(data) != "v2" {
+		t.Errorf("expected the file to be overwritten, got %q", data)
+	}
+}
+
+func TestWriteGeneratedGoFileFormats(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+	writeOptions = WriteOptions{}
+
+	src := "package models\n\nimport (\n    \"time\"\n    \"github.com/ggicci/httpin\"\n)\n\n\n\ntype Post struct {\n    Title string\n    PublishAt time.Time\n}\n"
+	if err := writeGeneratedGoFile("model.txt", "internal/models/post.go", []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("internal/models/post.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package models\n\nimport (\n\t\"time\"\n)\n\ntype Post struct {\n\tTitle     string\n\tPublishAt time.Time\n}\n"
+	if string(got) != want {
+		t.Errorf("expected formatted output without the unused import, got:\n%s", got)
+	}
+}
+
+func TestWriteGeneratedGoFileInvalid(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+	writeOptions = WriteOptions{}
+
+	if _, err := publishStubs([]string{"model.txt"}, false); err != nil {
+		t.Fatal(err)
+	}
+	src := "package models\n\ntype Post struct {\n    Title string `json:\"title\"\n}\n"
+	err := writeGeneratedGoFile("model.txt", "internal/models/post.go", []byte(src))
+	if err == nil {
+		t.Fatal("expected invalid Go to be rejected")
+	}
+	for _, want := range []string{"stub stubs/model.txt renders invalid Go for internal/models/post.go: line 4:", "     4 |     Title string `json:\"title\""} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected the error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if _, err := os.Stat("internal/models/post.go"); !os.IsNotExist(err) {
+		t.Error("expected the invalid file not to be written")
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := "a\nb\nc\nd\n"
+	ours := "a\nB\nc\nd\n"
+	theirs := "a\nb\nc\nd\ne\n"
+	if got, ok := merge3(base, ours, theirs, "scaffold"); !ok || got != "a\nB\nc\nd\ne\n" {
+		t.Errorf("merge3() = %q, %v", got, ok)
+	}
+
+	theirs = "a\nbb\nc\nd\n"
+	got, ok := merge3(base, ours, theirs, "scaffold")
+	want := "a\n<<<<<<< yours\nB\n=======\nbb\n>>>>>>> scaffold\nc\nd\n"
+	if ok || got != want {
+		t.Errorf("merge3() = %q, %v, want a conflict", got, ok)
+	}
+
+	if got, ok := merge3(base, "a\nB\nc\nd\n", "a\nB\nc\nd\n", "scaffold"); !ok || got != "a\nB\nc\nd\n" {
+		t.Errorf("expected identical changes to merge cleanly, got %q", got)
+	}
+}
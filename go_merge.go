@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// mergeGoFile merges a freshly generated Go file into the existing one. The
+// existing file is kept as written; what it lacks is added from the generated
+// file: functions and methods, types, vars and consts, fields of structs both
+// declare, and the imports these additions need.
+func mergeGoFile(existing, generated []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	ours, err := parser.ParseFile(fset, "existing.go", existing, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := parser.ParseFile(fset, "generated.go", generated, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("generated file: %w", err)
+	}
+	if ours.Name.Name != theirs.Name.Name {
+		return nil, fmt.Errorf("package %s doesn't match the generated package %s", ours.Name.Name, theirs.Name.Name)
+	}
+
+	funcs, typeSpecs, values := map[string]bool{}, map[string]*ast.TypeSpec{}, map[string]bool{}
+	for _, decl := range ours.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			funcs[funcKey(d)] = true
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					typeSpecs[s.Name.Name] = s
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						values[name.Name] = true
+					}
+				}
+			}
+		}
+	}
+
+	text := func(from ast.Node, to ast.Node) string {
+		return string(generated[fset.Position(from.Pos()).Offset:fset.Position(to.End()).Offset])
+	}
+
+	type insertion struct {
+		offset int
+		text   string
+	}
+	var inserts []insertion
+	var appended []string
+
+	for _, decl := range theirs.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !funcs[funcKey(d)] {
+				appended = append(appended, text(withDoc(d, d.Doc), d))
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+
+			var missing []ast.Spec
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					existingSpec := typeSpecs[s.Name.Name]
+					if existingSpec == nil {
+						missing = append(missing, s)
+						continue
+					}
+					ourStruct, ok1 := existingSpec.Type.(*ast.StructType)
+					theirStruct, ok2 := s.Type.(*ast.StructType)
+					if !ok1 || !ok2 {
+						continue
+					}
+					if fields := missingFields(ourStruct, theirStruct, text); len(fields) > 0 {
+						offset := fset.Position(ourStruct.Fields.Closing).Offset
+						text := strings.Join(fields, "\n") + "\n"
+						if offset > 0 && existing[offset-1] != '\n' {
+							text = "\n" + text
+						}
+						inserts = append(inserts, insertion{offset, text})
+					}
+				case *ast.ValueSpec:
+					if !slices.ContainsFunc(s.Names, func(name *ast.Ident) bool { return values[name.Name] }) {
+						missing = append(missing, s)
+					}
+				}
+			}
+
+			if len(missing) == len(d.Specs) {
+				// Keeps const groups with iota together.
+				appended = append(appended, text(withDoc(d, d.Doc), d))
+				continue
+			}
+			for _, spec := range missing {
+				var doc *ast.CommentGroup
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					doc = s.Doc
+				case *ast.ValueSpec:
+					doc = s.Doc
+				}
+				appended = append(appended, d.Tok.String()+" "+text(withDoc(spec, doc), spec))
+			}
+		}
+	}
+
+	if len(inserts) == 0 && len(appended) == 0 {
+		return existing, nil
+	}
+
+	out := slices.Clone(existing)
+	slices.SortFunc(inserts, func(a, b insertion) int { return b.offset - a.offset })
+	for _, ins := range inserts {
+		out = splice(out, ins.offset, ins.offset, ins.text)
+	}
+	if len(appended) > 0 {
+		out = append(bytes.TrimRight(out, "\n"), []byte("\n\n"+strings.Join(appended, "\n\n")+"\n")...)
+	}
+
+	out, err = mergeImports(out, theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("formatting the merged file: %w", err)
+	}
+	return formatted, nil
+}
+
+// missingFields returns the source of the fields of theirs that ours doesn't
+// have, matched by name, or by type for embedded fields.
+func missingFields(ours, theirs *ast.StructType, text func(from, to ast.Node) string) []string {
+	has := map[string]bool{}
+	for _, f := range ours.Fields.List {
+		if len(f.Names) == 0 {
+			has[types.ExprString(f.Type)] = true
+		}
+		for _, name := range f.Names {
+			has[name.Name] = true
+		}
+	}
+
+	var fields []string
+	for _, f := range theirs.Fields.List {
+		if len(f.Names) == 0 {
+			if !has[types.ExprString(f.Type)] {
+				fields = append(fields, fieldText(f, text))
+			}
+			continue
+		}
+
+		var missing []string
+		for _, name := range f.Names {
+			if !has[name.Name] {
+				missing = append(missing, name.Name)
+			}
+		}
+		switch {
+		case len(missing) == len(f.Names):
+			fields = append(fields, fieldText(f, text))
+		case len(missing) > 0:
+			field := strings.Join(missing, ", ") + " " + text(f.Type, f.Type)
+			if f.Tag != nil {
+				field += " " + f.Tag.Value
+			}
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// fieldText returns the source of a field with its doc and line comments.
+func fieldText(f *ast.Field, text func(from, to ast.Node) string) string {
+	var end ast.Node = f
+	if f.Comment != nil {
+		end = f.Comment
+	}
+	return text(withDoc(f, f.Doc), end)
+}
+
+// withDoc returns the doc comment of a node if it has one, so that the source
+// taken from it starts there, and the node itself otherwise.
+func withDoc(node ast.Node, doc *ast.CommentGroup) ast.Node {
+	if doc != nil {
+		return doc
+	}
+	return node
+}
+
+// funcKey identifies a function or method, e.g. PostHandler or
+// PostInput.Validate.
+func funcKey(fn *ast.FuncDecl) string {
+	if recv := receiverTypeName(fn); recv != "" {
+		return recv + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// mergeImports adds the imports of generated that src uses but doesn't
+// import yet, keeping their names.
+func mergeImports(src []byte, generated *ast.File) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	names, paths := map[string]bool{}, map[string]bool{}
+	for _, imp := range file.Imports {
+		names[importName(imp)] = true
+		paths[imp.Path.Value] = true
+	}
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				used[x.Name] = true
+			}
+		}
+		return true
+	})
+
+	for _, imp := range generated.Imports {
+		name := importName(imp)
+		blank := name == "_" || name == "."
+		if paths[imp.Path.Value] || !blank && (names[name] || !used[name]) {
+			continue
+		}
+		spec := imp.Path.Value
+		if imp.Name != nil {
+			spec = imp.Name.Name + " " + spec
+		}
+		src = addImportSpec(src, file, fset, spec)
+		if file, err = parser.ParseFile(fset, "", src, parser.ParseComments); err != nil {
+			return nil, err
+		}
+		names[name], paths[imp.Path.Value] = true, true
+	}
+	return src, nil
+}
+
+// importName returns the name an import is referred to by: its explicit name,
+// or the last element of its path without a major version or go- prefix.
+func importName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	p, _ := strconv.Unquote(imp.Path.Value)
+	name := path.Base(p)
+	if majorVersion.MatchString(name) && path.Dir(p) != "." {
+		name = path.Base(path.Dir(p))
+	}
+	name, _, _ = strings.Cut(name, ".")
+	name = strings.TrimPrefix(name, "go-")
+	return strings.ReplaceAll(name, "-", "")
+}
+
+var majorVersion = regexp.MustCompile(`^v\d+$`)
+
+// addImportSpec adds an import spec such as "fmt" or h "example.com/handlers"
+// to src, grouping it with the existing imports of file.
+func addImportSpec(src []byte, file *ast.File, fset *token.FileSet, spec string) []byte {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Lparen.IsValid() {
+			offset := fset.Position(gen.Lparen).Offset + 1
+			return splice(src, offset, offset, "\n\t"+spec)
+		}
+		start := fset.Position(gen.Pos()).Offset
+		end := fset.Position(gen.End()).Offset
+		existing := string(src[fset.Position(gen.Specs[0].Pos()).Offset:end])
+		return splice(src, start, end, "import (\n\t"+existing+"\n\t"+spec+"\n)")
+	}
+
+	offset := fset.Position(file.Name.End()).Offset
+	return splice(src, offset, offset, "\n\nimport "+spec)
+}
+
+func splice(src []byte, start, end int, insert string) []byte {
+	var buf bytes.Buffer
+	buf.Write(src[:start])
+	buf.WriteString(insert)
+	buf.Write(src[end:])
+	return buf.Bytes()
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeGoFileStructFields(t *testing.T) {
+	existing := `package models
+
+import "github.com/uptrace/bun"
+
+// Post is edited by hand.
+type Post struct {
+	bun.BaseModel
+	Id    uint64 ` + "`json:\"id\"`" + `
+	Title string ` + "`json:\"title\"`" + ` // shown in lists
+	Draft bool   // added by hand
+}
+
+func (p *Post) Published() bool {
+	return !p.Draft
+}
+`
+	generated := `package models
+
+import (
+    "time"
+
+    "github.com/uptrace/bun"
+)
+
+type Post struct {
+    bun.BaseModel
+    Id uint64 ` + "`json:\"id\"`" + `
+    Title string ` + "`json:\"title\"`" + `
+    // When the post goes live
+    PublishAt time.Time ` + "`json:\"publish_at\"`" + `
+}
+
+type PostStatus string
+`
+
+	merged, err := mergeGoFile([]byte(existing), []byte(generated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(merged)
+
+	for _, want := range []string{
+		"\t\"time\"\n",
+		"// Post is edited by hand.",
+		"\tTitle string `json:\"title\"` // shown in lists\n",
+		"\tDraft bool   // added by hand\n",
+		"\t// When the post goes live\n\tPublishAt time.Time `json:\"publish_at\"`\n}",
+		"func (p *Post) Published() bool {",
+		"type PostStatus string",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the merged file to contain:\n%s\ngot:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "Title string") != 1 || strings.Count(got, "bun.BaseModel") != 1 {
+		t.Errorf("expected existing fields not to be duplicated:\n%s", got)
+	}
+
+	again, err := mergeGoFile(merged, []byte(generated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != got {
+		t.Errorf("expected merging twice to be a no-op:\n%s", again)
+	}
+}
+
+func TestMergeGoFileFuncs(t *testing.T) {
+	existing := `package handlers
+
+import "github.com/lemmego/api/app"
+
+func PostIndexHandler(c app.Context) error {
+	// Edited by hand.
+	return c.JSON(app.M{"posts": nil})
+}
+`
+	generated := `package handlers
+
+import (
+    "net/http"
+
+    "github.com/lemmego/api/app"
+    "example.com/blog/internal/repos"
+)
+
+func PostIndexHandler(c app.Context) error {
+    posts, err := repos.Post(c.App()).All(c.RequestContext())
+    if err != nil {
+        return err
+    }
+    return c.JSON(app.M{"posts": posts})
+}
+
+// PostShowHandler shows a post.
+func PostShowHandler(c app.Context) error {
+    return c.Error(http.StatusNotFound, nil)
+}
+`
+
+	merged, err := mergeGoFile([]byte(existing), []byte(generated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(merged)
+
+	for _, want := range []string{
+		"// Edited by hand.\n\treturn c.JSON(app.M{\"posts\": nil})",
+		"// PostShowHandler shows a post.\nfunc PostShowHandler(c app.Context) error {\n\treturn c.Error(http.StatusNotFound, nil)\n}",
+		`"net/http"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the merged file to contain:\n%s\ngot:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "internal/repos") {
+		t.Errorf("expected imports only used by the generated index handler to be left out:\n%s", got)
+	}
+}
+
+func TestMergeGoFilePackageMismatch(t *testing.T) {
+	if _, err := mergeGoFile([]byte("package models\n"), []byte("package handlers\n")); err == nil {
+		t.Error("expected files of different packages not to be merged")
+	}
+}
+
+func TestModelGenerateMergesExistingFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+	writeOptions = WriteOptions{}
+
+	fields, err := ParseModelFields([]string{"title:string"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewModelGenerator(&ModelConfig{Name: "post", Fields: fields}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("internal", "models", "post.go")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = append(src, []byte("\nfunc (p *Post) Slug() string {\n\treturn p.Title\n}\n")...)
+	if err := os.WriteFile(path, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err = ParseModelFields([]string{"title:string", "views:int"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewModelGenerator(&ModelConfig{Name: "post", Fields: fields}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	model := readGoFile(t, path)
+	if !strings.Contains(model, "func (p *Post) Slug() string {") || !strings.Contains(model, "Views") {
+		t.Errorf("expected the new field to be merged into the edited model, got:\n%s", model)
+	}
+}
@@ -3,33 +3,129 @@ package cli
 import (
 	_ "embed"
 	"fmt"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
 
 	"github.com/charmbracelet/huh"
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
 
 	"strings"
 
-	"github.com/lemmego/fsys"
-
 	"github.com/spf13/cobra"
 )
 
 //go:embed handler.txt
 var handlerStub string
 
+//go:embed handler_plain.txt
+var handlerPlainStub string
+
+//go:embed handler_crud.txt
+var handlerCrudStub string
+
+//go:embed repo.txt
+var repoStub string
+
+// HandlerField is a field the store and update handlers copy from the input
+// onto the model.
 type HandlerField struct {
 	Name string
 }
 
+// handlerActions are the actions of a handler set, in the order they are
+// generated.
+var handlerActions = []string{"index", "create", "show", "store", "edit", "update", "delete"}
+
 type HandlerConfig struct {
-	Name string
+	Name    string
+	Model   string          // Generates CRUD bodies and a repository when set
+	Input   string          // Defaults to Model
+	Flavor  string          // Views rendered: templ, react, vue or gohtml; JSON responses when empty
+	ORM     OrmChoice       // ORM of the generated repository; GORM when empty
+	Fields  []*HandlerField // Read from the model and input when nil
+	Form    []*FormField    // Fields of the form of the create and edit handlers; read from the input when nil
+	Prefix  string          // Route group path the redirects point into
+	Methods []string        // Actions to generate; all of handlerActions when empty
+	Single  bool            // Generates a single <Name>Handler instead of a set
 }
 
 type HandlerGenerator struct {
-	name string
+	name    string
+	model   string
+	input   string
+	flavor  string
+	orm     OrmChoice
+	fields  []*HandlerField
+	form    []*FormField
+	prefix  string
+	methods []string
+	single  bool
 }
 
 func NewHandlerGenerator(mc *HandlerConfig) *HandlerGenerator {
-	return &HandlerGenerator{mc.Name}
+	input := mc.Input
+	if input == "" {
+		input = mc.Model
+	}
+	methods := mc.Methods
+	if len(methods) == 0 {
+		methods = handlerActions
+	}
+	return &HandlerGenerator{mc.Name, mc.Model, input, mc.Flavor, mc.ORM, mc.Fields, mc.Form, mc.Prefix, methods, mc.Single}
+}
+
+// ParseHandlerMethods splits a comma separated list of actions such as
+// "index,show" and checks them against handlerActions.
+func ParseHandlerMethods(values []string) ([]string, error) {
+	var methods []string
+	for _, value := range values {
+		for _, m := range strings.Split(value, ",") {
+			m = strings.ToLower(strings.TrimSpace(m))
+			if m == "" {
+				continue
+			}
+			if !slices.Contains(handlerActions, m) {
+				return nil, fmt.Errorf("unknown method %q (expected one of: %s)", m, strings.Join(handlerActions, ", "))
+			}
+			if !slices.Contains(methods, m) {
+				methods = append(methods, m)
+			}
+		}
+	}
+	return methods, nil
+}
+
+// Actions returns the actions the generator writes a handler for. REST API
+// handlers have no create and edit form actions.
+func (hg *HandlerGenerator) Actions() []string {
+	var actions []string
+	for _, a := range handlerActions {
+		if !slices.Contains(hg.methods, a) {
+			continue
+		}
+		if hg.flavor == "" && (a == "create" || a == "edit") {
+			continue
+		}
+		actions = append(actions, a)
+	}
+	return actions
+}
+
+// respond returns how the handlers respond: with JSON, or with inertia, templ
+// or gohtml pages.
+func (hg *HandlerGenerator) respond() string {
+	switch hg.flavor {
+	case "":
+		return "json"
+	case "react", "vue":
+		return "inertia"
+	}
+	return hg.flavor
 }
 
 func (hg *HandlerGenerator) GetPackagePath() string {
@@ -37,11 +133,20 @@ func (hg *HandlerGenerator) GetPackagePath() string {
 }
 
 func (hg *HandlerGenerator) GetStub() string {
-	return handlerStub
+	return loadStub(hg.stubName())
+}
+
+func (hg *HandlerGenerator) stubName() string {
+	if hg.single {
+		return "handler_plain.txt"
+	}
+	if hg.model != "" {
+		return "handler_crud.txt"
+	}
+	return "handler.txt"
 }
 
-func (hg *HandlerGenerator) Generate(appendable ...[]byte) error {
-	fs := fsys.NewLocalStorage("")
+func (hg *HandlerGenerator) Generate() error {
 	parts := strings.Split(hg.GetPackagePath(), "/")
 	packageName := hg.GetPackagePath()
 
@@ -49,13 +154,45 @@ func (hg *HandlerGenerator) Generate(appendable ...[]byte) error {
 		packageName = parts[len(parts)-1]
 	}
 
+	methods := map[string]bool{}
+	for _, a := range hg.Actions() {
+		methods[a] = true
+	}
 	tmplData := map[string]interface{}{
 		"PackageName": packageName,
 		"Name":        hg.name,
+		"Methods":     methods,
 	}
 
-	if len(appendable) > 0 {
-		tmplData["Appendable"] = string(appendable[0])
+	if hg.model != "" && !hg.single {
+		moduleName, err := GetModuleName()
+		if err != nil {
+			return err
+		}
+		if hg.fields == nil {
+			if hg.fields, err = handlerFields(hg.model, hg.input); err != nil {
+				return err
+			}
+		}
+		if err := NewRepoGenerator(&RepoConfig{Name: hg.model, ORM: hg.orm}).Generate(); err != nil {
+			return fmt.Errorf("generating repository: %w", err)
+		}
+
+		route := routePrefix(hg.prefix) + "/" + strcase.ToSnake(pluralize.NewClient().Plural(hg.name))
+		if hg.flavor != "" {
+			if err := hg.generateViews(route, methods); err != nil {
+				return err
+			}
+		}
+
+		tmplData["Module"] = moduleName
+		tmplData["Model"] = hg.model
+		tmplData["Input"] = hg.input
+		tmplData["Plural"] = pluralize.NewClient().Plural(hg.model)
+		tmplData["Route"] = route
+		tmplData["Respond"] = hg.respond()
+		tmplData["Views"] = resourceViews(hg.flavor, hg.model)
+		tmplData["Fields"] = hg.fields
 	}
 
 	output, err := ParseTemplate(tmplData, hg.GetStub(), CommonFuncs)
@@ -64,26 +201,143 @@ func (hg *HandlerGenerator) Generate(appendable ...[]byte) error {
 		return err
 	}
 
-	err = fs.Write(hg.GetPackagePath()+"/"+hg.name+"_handlers.go", []byte(output))
+	if hg.single {
+		return writeGeneratedGoFile(hg.stubName(), hg.GetPackagePath()+"/"+hg.name+"_handler.go", []byte(output))
+	}
+	return writeGeneratedGoFile(hg.stubName(), hg.GetPackagePath()+"/"+hg.name+"_handlers.go", []byte(output))
+}
 
-	if err != nil {
-		return err
+// generateViews writes the list and detail views the index and show handlers
+// render, and the form of the create and edit handlers.
+func (hg *HandlerGenerator) generateViews(route string, methods map[string]bool) error {
+	var actions []string
+	for _, a := range []string{"index", "show"} {
+		if methods[a] {
+			actions = append(actions, a)
+		}
+	}
+	if len(actions) > 0 {
+		vg := NewViewGenerator(&ViewConfig{Model: hg.model, Flavor: hg.flavor, Fields: hg.fields, Route: route, Actions: actions})
+		if err := vg.Generate(); err != nil {
+			return fmt.Errorf("generating views: %w", err)
+		}
 	}
 
+	if !methods["create"] && !methods["edit"] {
+		return nil
+	}
+	if hg.form == nil {
+		var err error
+		if hg.form, err = FormFieldsFromInput(hg.input); err != nil {
+			return err
+		}
+	}
+	fg := NewFormGenerator(&FormConfig{Name: hg.model, Flavor: hg.flavor, Fields: hg.form, Route: route})
+	if err := fg.Generate(); err != nil {
+		return fmt.Errorf("generating form: %w", err)
+	}
 	return nil
 }
 
+// handlerFields returns the fields that the input of internal/inputs and the
+// model of internal/models have in common, with the same type.
+func handlerFields(model, input string) ([]*HandlerField, error) {
+	strcase.ConfigureAcronym("id", "ID")
+	modelFields, err := goStructFields(filepath.Join("internal", "models", model+".go"), strcase.ToCamel(model))
+	if err != nil {
+		return nil, err
+	}
+	inputPath := filepath.Join("internal", "inputs", input+"_input.go")
+	inputFields, err := goStructFields(inputPath, strcase.ToCamel(input)+"Input")
+	if err != nil {
+		return nil, err
+	}
+
+	modelTypes := map[string]string{}
+	for _, f := range modelFields {
+		modelTypes[f.Name] = f.Type
+	}
+	var fields []*HandlerField
+	for _, f := range inputFields {
+		if modelType, ok := modelTypes[f.Name]; ok && modelType == f.Type {
+			fields = append(fields, &HandlerField{Name: f.Name})
+		}
+	}
+	if len(fields) == 0 {
+		fmt.Printf("Warning: %s has no fields in common with the %s model, fill%s copies nothing\n", inputPath, model, strcase.ToCamel(model))
+	}
+	return fields, nil
+}
+
+// goField is a field of a struct declared in a Go file.
+type goField struct {
+	Name string
+	Type string
+}
+
+// goStructFields parses the Go file at path and returns the exported fields
+// of the struct typeName.
+func goStructFields(path, typeName string) ([]goField, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), path, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	st := findStructType(file, typeName)
+	if st == nil {
+		return nil, fmt.Errorf("%s: no %s struct found", path, typeName)
+	}
+
+	var fields []goField
+	for _, f := range st.Fields.List {
+		for _, ident := range f.Names {
+			if ident.IsExported() {
+				fields = append(fields, goField{ident.Name, types.ExprString(f.Type)})
+			}
+		}
+	}
+	return fields, nil
+}
+
 func (hg *HandlerGenerator) Command() *cobra.Command {
 	return handlerCmd
 }
 
+var handlerModel, handlerInput string
+var handlerMethods []string
+var handlerSingle bool
+var handlerRouteOptions RouteOptions
+
+func init() {
+	handlerCmd.Flags().StringVar(&handlerModel, "model", "", "Model the handlers implement CRUD for, through a generated repository")
+	handlerCmd.Flags().StringVar(&handlerInput, "input", "", "Input the store and update handlers parse (defaults to --model)")
+	handlerCmd.Flags().StringSliceVar(&handlerMethods, "methods", nil, "Actions to generate, e.g. index,show (default all)")
+	handlerCmd.Flags().BoolVar(&handlerSingle, "single", false, "Generate a single <Name>Handler instead of a handler set")
+	handlerCmd.Flags().StringVar(&handlerRouteOptions.Prefix, "prefix", "", "Register the routes in a route group with this path")
+	handlerCmd.Flags().StringSliceVar(&handlerRouteOptions.Middleware, "middleware", nil, "Middleware to run before each route, e.g. auth.Protected")
+}
+
 var handlerCmd = &cobra.Command{
-	Use:     "handlers",
-	Aliases: []string{"h"},
-	Short:   "Generate a handler set",
-	Long:    `Generate a handler set`,
+	Use:     "handlers [name]",
+	Aliases: []string{"handler", "h"},
+	Short:   "Generate a handler set or a single handler",
+	Long: `Generate a handler set. With --model the index, show, create, store, edit, update and delete handlers are
+implemented against a repository in internal/repos, parse the input in internal/inputs and respond with JSON in
+rest_api projects, or with the Inertia, templ or gohtml pages of the project's frontend in mvc projects.
+--methods index,show generates only the given actions.
+
+The handlers are registered in internal/routes/web.go (mvc) or internal/routes/api.go (rest_api). --prefix puts
+them in a route group with that path, which is created when it doesn't exist, and --middleware runs the given
+handlers before each of them. Running the command again doesn't register a route twice.
+
+With --single a lone <Name>Handler is written to internal/handlers/<name>_handler.go instead, for an action that
+isn't part of a resource. It isn't registered, and like any handler in internal/handlers that internal/routes
+doesn't refer to, it is reported so that it isn't forgotten.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var handlerName string
+		var handlerName, middleware string
 
 		if !shouldRunInteractively && len(args) == 0 {
 			fmt.Println("Please provide a handler name")
@@ -91,13 +345,37 @@ var handlerCmd = &cobra.Command{
 		}
 
 		if shouldRunInteractively && len(args) == 0 {
+			handlerMethods = handlerActions
 			form := huh.NewForm(
 				huh.NewGroup(
 					huh.NewInput().
 						Title("Enter the resource name in snake_case").
 						Value(&handlerName).
 						Validate(SnakeCase),
+					huh.NewConfirm().
+						Title("Generate a single handler instead of a handler set?").
+						Value(&handlerSingle),
 				),
+				huh.NewGroup(
+					huh.NewMultiSelect[string]().
+						Title("Press x to select the actions to generate").
+						Options(huh.NewOptions(handlerActions...)...).
+						Value(&handlerMethods),
+					huh.NewInput().
+						Title("Enter the model to implement CRUD for (leave empty for empty handlers)").
+						Value(&handlerModel).
+						Validate(SnakeCaseEmptyAllowed),
+					huh.NewInput().
+						Title("Enter the input the handlers should parse (defaults to the model)").
+						Value(&handlerInput).
+						Validate(SnakeCaseEmptyAllowed),
+					huh.NewInput().
+						Title("Enter the route group path (leave empty for no group)").
+						Value(&handlerRouteOptions.Prefix),
+					huh.NewInput().
+						Title("Enter the middleware to run before the routes, comma separated (e.g. auth.Protected)").
+						Value(&middleware),
+				).WithHideFunc(func() bool { return handlerSingle }),
 			)
 
 			err := form.Run()
@@ -106,16 +384,69 @@ var handlerCmd = &cobra.Command{
 				fmt.Println(err)
 				return
 			}
+			if handlerSingle {
+				handlerMethods = nil
+			}
+			for _, m := range strings.Split(middleware, ",") {
+				if m = strings.TrimSpace(m); m != "" {
+					handlerRouteOptions.Middleware = append(handlerRouteOptions.Middleware, m)
+				}
+			}
 		} else {
 			handlerName = args[0]
 		}
 
-		mg := NewHandlerGenerator(&HandlerConfig{Name: handlerName})
-		err := mg.Generate()
+		if handlerInput != "" && handlerModel == "" {
+			fmt.Println("Error: --input needs --model")
+			return
+		}
+		if handlerSingle && (handlerModel != "" || len(handlerMethods) > 0 || handlerRouteOptions.Prefix != "" || len(handlerRouteOptions.Middleware) > 0) {
+			fmt.Println("Error: --single can't be combined with --model, --methods, --prefix or --middleware")
+			return
+		}
+		methods, err := ParseHandlerMethods(handlerMethods)
 		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		pm := currentProjectManifest()
+		mg := NewHandlerGenerator(&HandlerConfig{
+			Name:    handlerName,
+			Model:   handlerModel,
+			Input:   handlerInput,
+			Flavor:  detectViewFlavor(pm),
+			ORM:     detectProjectORM(pm),
+			Prefix:  handlerRouteOptions.Prefix,
+			Methods: methods,
+			Single:  handlerSingle,
+		})
+		if err := mg.Generate(); err != nil {
 			fmt.Println(err)
 			return
 		}
 		fmt.Println("Handler generated successfully.")
+
+		if !handlerSingle {
+			handlerRouteOptions.Actions = methods
+			routesFile, err := RegisterResourceRoutes(handlerName, detectProjectPreset(pm), handlerRouteOptions)
+			if err != nil {
+				fmt.Println("Error registering routes:", err)
+				return
+			}
+			fmt.Printf("Routes registered in %s.\n", routesFile)
+		}
+
+		if writeOptions.DryRun || writeOptions.Diff {
+			return
+		}
+		unreferenced, err := unreferencedHandlers()
+		if err != nil {
+			fmt.Println("Error checking routes:", err)
+			return
+		}
+		for _, name := range unreferenced {
+			fmt.Printf("Warning: handlers.%s is not referenced in internal/routes\n", name)
+		}
 	},
 }
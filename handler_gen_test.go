@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHandlerGenerateCRUD(t *testing.T) {
+	for _, flavor := range []string{"", "react", "vue", "templ", "gohtml"} {
+		t.Run(flavor, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile("go.mod", []byte("module example.com/blog\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			modelFields, err := ParseModelFields([]string{"title:string:required", "body:text"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := NewModelGenerator(&ModelConfig{Name: "post", Fields: modelFields}).Generate(); err != nil {
+				t.Fatal(err)
+			}
+			inputFields, err := ParseInputFields([]string{"title:string:required", "body:string", "cover:file"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := NewInputGenerator(&InputConfig{Name: "post", Fields: inputFields}).Generate(); err != nil {
+				t.Fatal(err)
+			}
+
+			hg := NewHandlerGenerator(&HandlerConfig{Name: "post", Model: "post", Flavor: flavor})
+			if err := hg.Generate(); err != nil {
+				t.Fatal(err)
+			}
+
+			handlers := readGoFile(t, filepath.Join("internal", "handlers", "post_handlers.go"))
+			readGoFile(t, filepath.Join("internal", "repos", "post_repo.go"))
+
+			want := []string{
+				`"example.com/blog/internal/repos"`,
+				`input, err := inputs.NewPostInput(c)`,
+				`post, err := repos.Post(c.App()).Find(c.RequestContext(), c.Param("id"))`,
+				"post.Title = input.Title\n\tpost.Body = input.Body\n}",
+			}
+			switch flavor {
+			case "":
+				want = append(want, `return c.JSON(app.M{"posts": posts})`, `c.SetStatus(http.StatusCreated)`)
+			case "react", "vue":
+				want = append(want, `return inertia.Respond(c, "Posts/Index", app.M{"posts": posts})`, `return inertia.Respond(c, "Forms/Post", nil)`)
+			case "templ":
+				want = append(want,
+					`"example.com/blog/templates"`,
+					`return templates.BaseLayout(templates.PostIndex(posts)).Render(c.RequestContext(), c.ResponseWriter())`,
+					`templates.BaseLayout(templates.Post("/posts/"+c.Param("id"), http.MethodPut, errors))`,
+				)
+			case "gohtml":
+				want = append(want, `res.NewTemplate(c, "post_show.page.gohtml")`, `res.NewTemplate(c, "post.page.gohtml")`, `return c.Redirect("/posts")`)
+			}
+			for _, w := range want {
+				if !strings.Contains(handlers, w) {
+					t.Errorf("expected handlers to contain:\n%s\ngot:\n%s", w, handlers)
+				}
+			}
+			file, _ := parser.ParseFile(token.NewFileSet(), "", handlers, 0)
+			if unused := unusedImports(file); len(unused) > 0 {
+				t.Errorf("expected every import to be used, %v are not", unused)
+			}
+			if hasCreate := strings.Contains(handlers, "PostCreateHandler"); hasCreate == (flavor == "") {
+				t.Errorf("expected the create handler only for pages, got:\n%s", handlers)
+			}
+
+			if flavor == "" {
+				if _, err := os.Stat("templates"); err == nil {
+					t.Error("expected no views for JSON handlers")
+				}
+				return
+			}
+			views := resourceViewFiles(flavor, "post")
+			for _, file := range []string{views.Index, views.Show, views.Form} {
+				if _, err := os.Stat(file); err != nil {
+					t.Errorf("expected the handlers to come with %s: %v", file, err)
+				}
+			}
+		})
+	}
+}
+
+// TestHandlerGenerateTemplCompiles builds the handlers, views and form of a
+// templ project, along with the layout and partials the scaffold gives it.
+func TestHandlerGenerateTemplCompiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+	writeOptions = WriteOptions{}
+	offlineModule(t, generatedCodeRequires(t))
+
+	src, _ := embeddedScaffold()
+	if err := os.Mkdir("templates", 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"base-layout.templ", "csrf.templ", "method.templ"} {
+		data, err := fs.ReadFile(src.fs, path.Join(src.prefix, "overlays", "frontend_templ", "templates", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join("templates", name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	modelFields, err := ParseModelFields([]string{"title:string:required", "views:uint", "published_at:date"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewModelGenerator(&ModelConfig{Name: "blog_post", Fields: append(append([]*ModelField{}, CommonModelFields...), modelFields...)}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+	inputFields, err := ParseInputFields([]string{"title:string:required:unique=blog_posts", "views:uint", "published_at:date"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewInputGenerator(&InputConfig{Name: "blog_post", Fields: inputFields}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewHandlerGenerator(&HandlerConfig{Name: "blog_post", Model: "blog_post", Flavor: "templ", Prefix: "/admin"}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	goOffline(t, "run", "github.com/a-h/templ/cmd/templ", "generate")
+	goOffline(t, "build", "./...")
+}
+
+func TestRepoGenerateBun(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("go.mod", []byte("module example.com/blog\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewRepoGenerator(&RepoConfig{Name: "blog_post", ORM: OrmBun}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+	repo := readGoFile(t, filepath.Join("internal", "repos", "blog_post_repo.go"))
+	for _, want := range []string{
+		`func BlogPost(a app.App) *BlogPostRepository {`,
+		`err := r.db.NewSelect().Model(&blogPosts).Scan(ctx)`,
+		`_, err := r.db.NewDelete().Model((*models.BlogPost)(nil)).Where("id = ?", id).Exec(ctx)`,
+	} {
+		if !strings.Contains(repo, want) {
+			t.Errorf("expected repository to contain:\n%s\ngot:\n%s", want, repo)
+		}
+	}
+}
+
+func TestHandlerGenerateWithoutModelFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("go.mod", []byte("module example.com/blog\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewHandlerGenerator(&HandlerConfig{Name: "post", Model: "post"}).Generate(); err == nil {
+		t.Error("expected a missing model to be reported")
+	}
+}
+
+func TestHandlerGenerateMethods(t *testing.T) {
+	for _, tt := range []struct {
+		flavor  string
+		methods []string
+		want    []string
+	}{
+		{"gohtml", []string{"index", "show"}, []string{"PostIndexHandler", "PostShowHandler"}},
+		{"templ", []string{"create"}, []string{"PostCreateHandler"}},
+		{"react", []string{"store", "delete"}, []string{"PostStoreHandler", "PostDeleteHandler", "fillPost"}},
+		{"", []string{"edit", "delete"}, []string{"PostDeleteHandler"}},
+	} {
+		t.Run(tt.flavor, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile("go.mod", []byte("module example.com/blog\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			hg := NewHandlerGenerator(&HandlerConfig{
+				Name:    "post",
+				Model:   "post",
+				Flavor:  tt.flavor,
+				Fields:  []*HandlerField{{Name: "Title"}},
+				Form:    []*FormField{{Name: "title", Type: "text"}},
+				Methods: tt.methods,
+			})
+			if err := hg.Generate(); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("internal", "handlers", "post_handlers.go")
+			file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var funcs []string
+			for _, decl := range file.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					funcs = append(funcs, fn.Name.Name)
+				}
+			}
+			if !reflect.DeepEqual(funcs, tt.want) {
+				t.Errorf("expected functions %v, got %v", tt.want, funcs)
+			}
+			if unused := unusedImports(file); len(unused) > 0 {
+				t.Errorf("expected every import to be used, %v are not", unused)
+			}
+		})
+	}
+}
+
+func TestHandlerGenerateSingle(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := NewHandlerGenerator(&HandlerConfig{Name: "export_report", Single: true}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+	handler := readGoFile(t, filepath.Join("internal", "handlers", "export_report_handler.go"))
+	if !strings.Contains(handler, "func ExportReportHandler(ctx app.Context) error {") || strings.Contains(handler, "api.") {
+		t.Errorf("expected a single app.Context handler, got:\n%s", handler)
+	}
+}
+
+func TestUnreferencedHandlers(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("go.mod", []byte("module example.com/blog\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"routes", "handlers"} {
+		if err := os.MkdirAll(filepath.Join("internal", dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handlers := map[string]string{
+		"post_handlers.go": "package handlers\n\nfunc PostIndexHandler() {}\n\nfunc PostShowHandler() {}\n\nfunc postHelperHandler() {}\n",
+		"legacy.go":        "package handlers\n\nfunc ImportHandler() {}\n\nfunc Import() {}\n",
+		"legacy_test.go":   "package handlers\n\nfunc TestImportHandler() {}\n",
+	}
+	for name, src := range handlers {
+		if err := os.WriteFile(filepath.Join("internal", "handlers", name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	routes := `package routes
+
+import (
+	"github.com/lemmego/api/app"
+	h "example.com/blog/internal/handlers"
+)
+
+func WebRoutes(a app.App) {
+	r := a.Router()
+	r.Get("/posts", h.PostIndexHandler)
+}
+`
+	if err := os.WriteFile(filepath.Join("internal", "routes", "web.go"), []byte(routes), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	unreferenced, err := unreferencedHandlers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ImportHandler", "PostShowHandler"}; !reflect.DeepEqual(unreferenced, want) {
+		t.Errorf("expected %v to be unreferenced, got %v", want, unreferenced)
+	}
+}
+
+func TestParseHandlerMethods(t *testing.T) {
+	methods, err := ParseHandlerMethods([]string{"index, Show", "index"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"index", "show"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("expected %v, got %v", want, methods)
+	}
+	if _, err := ParseHandlerMethods([]string{"list"}); err == nil {
+		t.Error("expected an unknown method to be rejected")
+	}
+}
+
+// unusedImports returns the imports of file whose package name is never
+// referred to.
+func unusedImports(file *ast.File) []string {
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				used[x.Name] = true
+			}
+		}
+		return true
+	})
+	var unused []string
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		if !used[path.Base(p)] {
+			unused = append(unused, p)
+		}
+	}
+	return unused
+}
+
+// readGoFile returns the contents of a generated Go file, failing the test
+// if it doesn't parse.
+func readGoFile(t *testing.T, path string) string {
+	t.Helper()
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), path, src, 0); err != nil {
+		t.Fatalf("%s does not parse: %v\n%s", path, err, src)
+	}
+	return string(src)
+}
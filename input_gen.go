@@ -6,8 +6,6 @@ import (
 	"slices"
 	"strings"
 
-	"github.com/lemmego/fsys"
-
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -24,22 +22,78 @@ type InputField struct {
 	Type         string
 	Required     bool
 	Unique       bool
-	Table        string
-	WhereClauses []map[string]interface{}
+	Table        string                   // Table, or table.column, checked by Unique
+	Exists       string                   // Table, or table.column, the value must exist in
+	WhereClauses []map[string]interface{} // Extra conditions of the Unique and Exists checks
+	Min          string                   // Minimum value, or length for strings
+	Max          string                   // Maximum value, or length for strings
+	Email        bool
+	URL          bool
+	Regex        string
+	In           []string
+	Before       string // 2006-01-02 or now
+	After        string // 2006-01-02 or now
+	Confirmed    bool   // Adds a <name>_confirmation field that must equal this one
+	Confirms     string // Name of the field this one must equal
 }
 
 type InputConfig struct {
 	Name   string
+	ORM    OrmChoice // ORM the unique and exists rules query through; GORM when empty
 	Fields []*InputField
 }
 
 type InputGenerator struct {
 	name   string
+	orm    OrmChoice
 	fields []*InputField
 }
 
+// ParseInputFields converts command-line field specs such as
+// "email:string:required:unique=users" into input fields. The modifiers are
+// the rules of inputRules: unique and exists take the table that should be
+// checked and can be narrowed with where=column=value, min and max a whole
+// number, regex a pattern, in a comma separated list and before and after a
+// date.
+// UI types other than file are converted through UiDataTypeMap.
+func ParseInputFields(specs []string) ([]*InputField, error) {
+	parsed, err := ParseFieldSpecs(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []*InputField
+	for _, spec := range parsed {
+		if spec.Type == "custom" {
+			return nil, fmt.Errorf("field %q: give the Go type itself instead of custom", spec.Name)
+		}
+		if err := spec.checkModifiers(inputRules...); err != nil {
+			return nil, err
+		}
+		if goType, ok := UiDataTypeMap[spec.Type]; ok && spec.Type != "file" {
+			spec.Type = goType
+		}
+		field := &InputField{Name: spec.Name, Type: spec.Type}
+		for _, m := range spec.Modifiers {
+			rule, value, _ := strings.Cut(m, "=")
+			if err := applyInputRule(field, rule, value); err != nil {
+				return nil, err
+			}
+		}
+		if len(field.WhereClauses) > 0 && !field.Unique && field.Exists == "" {
+			return nil, fmt.Errorf("field %q: where only applies to unique and exists", spec.Name)
+		}
+		fields = append(fields, field)
+	}
+	return withConfirmations(fields), nil
+}
+
 func NewInputGenerator(mc *InputConfig) *InputGenerator {
-	return &InputGenerator{mc.Name, mc.Fields}
+	orm := mc.ORM
+	if orm == "" {
+		orm = OrmGORM
+	}
+	return &InputGenerator{mc.Name, orm, mc.Fields}
 }
 
 func (ig *InputGenerator) GetPackagePath() string {
@@ -47,11 +101,10 @@ func (ig *InputGenerator) GetPackagePath() string {
 }
 
 func (ig *InputGenerator) GetStub() string {
-	return inputStub
+	return loadStub("input.txt")
 }
 
-func (ig *InputGenerator) Generate(appendable ...[]byte) error {
-	fs := fsys.NewLocalStorage("")
+func (ig *InputGenerator) Generate() error {
 	parts := strings.Split(ig.GetPackagePath(), "/")
 	packageName := ig.GetPackagePath()
 
@@ -62,26 +115,17 @@ func (ig *InputGenerator) Generate(appendable ...[]byte) error {
 	tmplData := map[string]interface{}{
 		"PackageName": packageName,
 		"InputName":   ig.name,
+		"ORM":         ig.orm,
 		"Fields":      ig.fields,
 	}
 
-	if len(appendable) > 0 {
-		tmplData["Appendable"] = string(appendable[0])
-	}
-
 	output, err := ParseTemplate(tmplData, ig.GetStub(), CommonFuncs)
 
 	if err != nil {
 		return err
 	}
 
-	err = fs.Write(ig.GetPackagePath()+"/"+ig.name+"_input.go", []byte(output))
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return writeGeneratedGoFile("input.txt", ig.GetPackagePath()+"/"+ig.name+"_input.go", []byte(output))
 }
 
 func (ig *InputGenerator) Command() *cobra.Command {
@@ -89,7 +133,7 @@ func (ig *InputGenerator) Command() *cobra.Command {
 }
 
 var inputCmd = &cobra.Command{
-	Use:   "input",
+	Use:   "input [name] [field:type[:modifier]...]",
 	Short: "Generate a request input",
 	Long:  `Generate a request input`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -117,10 +161,7 @@ var inputCmd = &cobra.Command{
 
 			for {
 				var fieldName, fieldType string
-				const required = "Required"
-				const unique = "Unique"
 				selectedAttrs := []string{}
-				tableNameForUniqueField := ""
 				fieldNameForm := huh.NewForm(
 					huh.NewGroup(
 						huh.NewInput().
@@ -165,8 +206,8 @@ var inputCmd = &cobra.Command{
 				selectedAttrsForm := huh.NewForm(
 					huh.NewGroup(
 						huh.NewMultiSelect[string]().
-							Title("Press x to select the attributes").
-							Options(huh.NewOptions(required, unique)...).
+							Title("Press x to select the validation rules").
+							Options(huh.NewOptions(slices.DeleteFunc(slices.Clone(inputRules), func(rule string) bool { return rule == "where" })...)...).
 							Value(&selectedAttrs),
 					),
 				)
@@ -175,27 +216,63 @@ var inputCmd = &cobra.Command{
 					return
 				}
 
-				if slices.Contains(selectedAttrs, unique) {
+				field := &InputField{Name: fieldName, Type: fieldType}
+				for _, rule := range selectedAttrs {
+					var value string
+					if title, ok := inputRuleTitles[rule]; ok {
+						err := huh.NewInput().
+							Title(title).
+							Validate(func(s string) error { return checkInputRule(fieldType, rule, s) }).
+							Value(&value).
+							Run()
+						if err != nil {
+							return
+						}
+					}
+					if err := applyInputRule(field, rule, value); err != nil {
+						fmt.Println(err)
+						return
+					}
+				}
+
+				for field.Unique || field.Exists != "" {
+					var clause string
 					err := huh.NewInput().
-						Title("Which db table should be checked for uniqueness?").Value(&tableNameForUniqueField).Run()
+						Title("Add a where clause to the table check as column=value (Press enter to finish)").
+						Validate(func(s string) error {
+							if s == "" {
+								return nil
+							}
+							return checkInputRule(fieldType, "where", s)
+						}).
+						Value(&clause).
+						Run()
 					if err != nil {
 						return
 					}
+					if clause == "" {
+						break
+					}
+					if err := applyInputRule(field, "where", clause); err != nil {
+						fmt.Println(err)
+						return
+					}
 				}
 
-				fields = append(fields, &InputField{
-					Name:     fieldName,
-					Type:     fieldType,
-					Required: slices.Contains(selectedAttrs, required),
-					Unique:   slices.Contains(selectedAttrs, unique),
-					Table:    tableNameForUniqueField,
-				})
+				fields = append(fields, field)
 			}
+			fields = withConfirmations(fields)
 		} else {
 			inputName = args[0]
+			var err error
+			fields, err = ParseInputFields(args[1:])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
 		}
 
-		ig := NewInputGenerator(&InputConfig{Name: inputName, Fields: fields})
+		ig := NewInputGenerator(&InputConfig{Name: inputName, ORM: detectProjectORM(currentProjectManifest()), Fields: fields})
 		err := ig.Generate()
 		if err != nil {
 			fmt.Println(err)
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInputGenerateRules(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	fields, err := ParseInputFields([]string{
+		"name:string:required:min=2:max=255",
+		"email:string:required:email:unique=users:where=tenant_id=1",
+		"website:string:url:regex=^[a-z.]+$",
+		"status:string:in=draft,published",
+		"password:string:required:confirmed",
+		"starts_at:date:after=now:before=2030-01-31",
+		"team_id:uint:exists=teams.id",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewInputGenerator(&InputConfig{Name: "signup", Fields: fields}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.ReadFile(filepath.Join("internal", "inputs", "signup_input.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", out, 0); err != nil {
+		t.Fatalf("generated input does not parse: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		`"time"`,
+		`"gorm.io/gorm"`,
+		`PasswordConfirmation string`,
+		`v.Field("name", i.Name).Required().Min(2).Max(255)`,
+		`v.Field("email", i.Email).Required().Email().Custom(func(value any) (bool, string) {`,
+		`err := app.Get[*gorm.DB](i.ctx.App()).WithContext(i.ctx.RequestContext()).Table("users").Where("email = ?", value).Where("tenant_id = ?", 1).Count(&count).Error`,
+		`return err == nil && count == 0, "This field has already been taken"`,
+		"v.Field(\"website\", i.Website).URL().Regex(`^[a-z.]+$`)",
+		`v.Field("status", i.Status).In([]string{"draft", "published"})`,
+		`v.Field("password_confirmation", i.PasswordConfirmation).Required().Equals(i.Password)`,
+		`v.Field("starts_at", i.StartsAt).BeforeDate(time.Date(2030, time.January, 31, 0, 0, 0, 0, time.UTC)).AfterDate(time.Now())`,
+		`Table("teams").Where("id = ?", value).Count(&count).Error`,
+		`return err == nil && count > 0, "This field must exist in teams"`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected generated input to contain:\n%s\ngot:\n%s", want, out)
+		}
+	}
+}
+
+// offlineModule writes a go.mod for example.com/app requiring the given
+// module versions to the current directory. It skips the test when a required
+// module isn't in the module cache, since goOffline can't download it.
+func offlineModule(t *testing.T, requires map[string]string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds a module")
+	}
+	modCache, err := exec.Command("go", "env", "GOMODCACHE").Output()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	goMod := "module example.com/app\n\ngo 1.25.0\n\nrequire (\n"
+	for _, path := range slices.Sorted(maps.Keys(requires)) {
+		version := requires[path]
+		zip := filepath.Join(strings.TrimSpace(string(modCache)), "cache", "download", path, "@v", version+".zip")
+		if _, err := os.Stat(zip); err != nil {
+			t.Skipf("%s@%s is not in the module cache", path, version)
+		}
+		goMod += fmt.Sprintf("\t%s %s\n", path, version)
+	}
+	if err := os.WriteFile("go.mod", []byte(goMod+")\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// goOffline runs the go command in the current directory with only the
+// module cache.
+func goOffline(t *testing.T, args ...string) {
+	t.Helper()
+	cmd := exec.Command("go", args...)
+	cmd.Env = append(os.Environ(), "GOPROXY=off", "GOFLAGS=-mod=mod", "GOSUMDB=off", "GOWORK=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// generatedCodeRequires returns the modules generated code is built against
+// in tests: the lemmego/api and templ versions new projects get and the ORMs.
+func generatedCodeRequires(t *testing.T) map[string]string {
+	t.Helper()
+	src, _ := embeddedScaffold()
+	versions := loadVersions(src)
+	return map[string]string{
+		"github.com/lemmego/api": versions["github.com/lemmego/api"],
+		"github.com/a-h/templ":   versions["github.com/a-h/templ"],
+		"gorm.io/gorm":           "v1.31.1",
+		"github.com/uptrace/bun": "v1.2.16",
+	}
+}
+
+// TestInputGenerateCompiles builds generated inputs against lemmego/api and
+// the ORMs, so the rules have to be real validator calls and queries.
+func TestInputGenerateCompiles(t *testing.T) {
+	for _, orm := range []OrmChoice{OrmGORM, OrmBun} {
+		t.Run(string(orm), func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+			writeOptions = WriteOptions{}
+
+			fields, err := ParseInputFields([]string{
+				"name:string:required:min=2:max=255",
+				"email:string:required:email:unique=users:where=tenant_id=1",
+				"website:string:url:regex=^[a-z.]+$",
+				"status:string:in=draft,published",
+				"password:string:required:confirmed",
+				"starts_at:date:after=now:before=2030-01-31",
+				"age:uint:min=18",
+				"team_id:uint:exists=teams.id",
+				"avatar:file",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := NewInputGenerator(&InputConfig{Name: "signup", ORM: orm, Fields: fields}).Generate(); err != nil {
+				t.Fatal(err)
+			}
+			offlineModule(t, generatedCodeRequires(t))
+			goOffline(t, "build", "./...")
+		})
+	}
+}
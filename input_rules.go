@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"fmt"
+	"html/template"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iancoleman/strcase"
+)
+
+// inputRules lists the validation modifiers a gen input field can take, in
+// the order they are chained onto the validator.
+var inputRules = []string{"required", "min", "max", "email", "url", "regex", "in", "before", "after", "confirmed", "unique", "exists", "where"}
+
+// inputRuleTitles are the prompts for the value of the rules that take one.
+var inputRuleTitles = map[string]string{
+	"min":    "Minimum value, or minimum length for strings",
+	"max":    "Maximum value, or maximum length for strings",
+	"regex":  "Regular expression the value must match",
+	"in":     "Allowed values, comma separated",
+	"before": "Date the value must be before (2006-01-02 or now)",
+	"after":  "Date the value must be after (2006-01-02 or now)",
+	"unique": "Table the value must be unique in (table or table.column)",
+	"exists": "Table the value must exist in (table or table.column)",
+}
+
+// checkInputRule reports whether rule can be applied to a field of the given
+// Go type with value.
+func checkInputRule(fieldType, rule, value string) error {
+	isString := fieldType == "string"
+	isNumber := strings.HasPrefix(fieldType, "int") || strings.HasPrefix(fieldType, "uint") || strings.HasPrefix(fieldType, "float")
+
+	switch rule {
+	case "required", "confirmed":
+	case "min", "max":
+		if !isString && !isNumber {
+			return fmt.Errorf("%s only applies to strings and numbers", rule)
+		}
+		if !intLiteral.MatchString(value) {
+			return fmt.Errorf("%s needs a whole number, e.g. %s=3", rule, rule)
+		}
+	case "email", "url", "regex":
+		if !isString {
+			return fmt.Errorf("%s only applies to strings", rule)
+		}
+		if _, err := regexp.Compile(value); rule == "regex" && (value == "" || err != nil) {
+			return fmt.Errorf("regex needs a valid regular expression")
+		}
+	case "in":
+		if !isString {
+			return fmt.Errorf("in only applies to strings")
+		}
+		if value == "" {
+			return fmt.Errorf("in needs the allowed values, e.g. in=draft,published")
+		}
+	case "before", "after":
+		if fieldType != "time.Time" {
+			return fmt.Errorf("%s only applies to time.Time", rule)
+		}
+		if _, err := time.Parse(time.DateOnly, value); value != "now" && err != nil {
+			return fmt.Errorf("%s needs a date as 2006-01-02 or now", rule)
+		}
+	case "unique", "exists":
+		if table, _, _ := strings.Cut(value, "."); table == "" {
+			return fmt.Errorf("%s needs a table, e.g. %s=users", rule, rule)
+		}
+	case "where":
+		if column, _, ok := strings.Cut(value, "="); column == "" || !ok {
+			return fmt.Errorf("where needs a column and a value, e.g. where=tenant_id=1")
+		}
+	default:
+		return fmt.Errorf("unknown modifier %q (expected one of: %s)", rule, strings.Join(inputRules, ", "))
+	}
+	return nil
+}
+
+// applyInputRule checks rule and sets it on the field.
+func applyInputRule(f *InputField, rule, value string) error {
+	if err := checkInputRule(f.Type, rule, value); err != nil {
+		return fmt.Errorf("field %q: %w", f.Name, err)
+	}
+
+	switch rule {
+	case "required":
+		f.Required = true
+	case "min":
+		f.Min = value
+	case "max":
+		f.Max = value
+	case "email":
+		f.Email = true
+	case "url":
+		f.URL = true
+	case "regex":
+		f.Regex = value
+	case "in":
+		f.In = strings.Split(value, ",")
+	case "before":
+		f.Before = value
+	case "after":
+		f.After = value
+	case "confirmed":
+		f.Confirmed = true
+	case "unique":
+		f.Unique = true
+		f.Table = value
+	case "exists":
+		f.Exists = value
+	case "where":
+		column, v, _ := strings.Cut(value, "=")
+		f.WhereClauses = append(f.WhereClauses, map[string]interface{}{column: v})
+	}
+	return nil
+}
+
+// Rules returns the validator calls chained onto v.Field for the field, such
+// as Required() or In([]string{"draft", "published"}). The unique and exists
+// checks count the matching rows through the project's orm in a Custom rule.
+func (f *InputField) Rules(orm OrmChoice) []template.HTML {
+	var rules []string
+	if f.Required {
+		rules = append(rules, "Required()")
+	}
+	if f.Min != "" {
+		rules = append(rules, "Min("+f.Min+")")
+	}
+	if f.Max != "" {
+		rules = append(rules, "Max("+f.Max+")")
+	}
+	if f.Email {
+		rules = append(rules, "Email()")
+	}
+	if f.URL {
+		rules = append(rules, "URL()")
+	}
+	if f.Regex != "" {
+		pattern := "`" + f.Regex + "`"
+		if strings.Contains(f.Regex, "`") {
+			pattern = strconv.Quote(f.Regex)
+		}
+		rules = append(rules, "Regex("+pattern+")")
+	}
+	if len(f.In) > 0 {
+		var values []string
+		for _, v := range f.In {
+			values = append(values, strconv.Quote(v))
+		}
+		rules = append(rules, "In([]string{"+strings.Join(values, ", ")+"})")
+	}
+	if f.Before != "" {
+		rules = append(rules, "BeforeDate("+timeExpr(f.Before)+")")
+	}
+	if f.After != "" {
+		rules = append(rules, "AfterDate("+timeExpr(f.After)+")")
+	}
+	if f.Confirms != "" {
+		strcase.ConfigureAcronym("id", "ID")
+		rules = append(rules, "Equals(i."+strcase.ToCamel(f.Confirms)+")")
+	}
+	if f.Unique {
+		rules = append(rules, f.countRule(orm, f.Table, "count == 0", "This field has already been taken"))
+	}
+	if f.Exists != "" {
+		rules = append(rules, f.countRule(orm, f.Exists, "count > 0", "This field must exist in "+strings.Split(f.Exists, ".")[0]))
+	}
+
+	html := make([]template.HTML, len(rules))
+	for i, rule := range rules {
+		html[i] = template.HTML(rule)
+	}
+	return html
+}
+
+// UsesTime reports whether the generated input needs the time package for
+// the field.
+func (f *InputField) UsesTime() bool {
+	return f.Type == "time.Time" || f.Before != "" || f.After != ""
+}
+
+// countRule returns a Custom rule that counts the rows of table, given as
+// table or table.column, that match the value and the where clauses, and
+// passes when the count satisfies cond.
+func (f *InputField) countRule(orm OrmChoice, table, cond, message string) string {
+	table, column, _ := strings.Cut(table, ".")
+	if column == "" {
+		column = strcase.ToSnake(f.Name)
+	}
+	query := fmt.Sprintf(".Table(%q).Where(%q, value)", table, column+" = ?")
+	for _, clause := range f.WhereClauses {
+		for _, key := range slices.Sorted(maps.Keys(clause)) {
+			query += fmt.Sprintf(".Where(%q, %s)", key+" = ?", goLiteral(fmt.Sprint(clause[key])))
+		}
+	}
+
+	var count string
+	if orm == OrmBun {
+		count = "count, err := app.Get[*bun.DB](i.ctx.App()).NewSelect()" + query + ".Count(i.ctx.RequestContext())"
+	} else {
+		count = "var count int64\nerr := app.Get[*gorm.DB](i.ctx.App()).WithContext(i.ctx.RequestContext())" + query + ".Count(&count).Error"
+	}
+	return fmt.Sprintf("Custom(func(value any) (bool, string) {\n%s\nreturn err == nil && %s, %q\n})", count, cond, message)
+}
+
+// withConfirmations adds a <name>_confirmation field after every confirmed
+// field that doesn't have one yet.
+func withConfirmations(fields []*InputField) []*InputField {
+	var result []*InputField
+	for _, f := range fields {
+		result = append(result, f)
+		name := f.Name + "_confirmation"
+		if !f.Confirmed || slices.ContainsFunc(fields, func(other *InputField) bool { return other.Name == name }) {
+			continue
+		}
+		result = append(result, &InputField{Name: name, Type: f.Type, Required: f.Required, Confirms: f.Name})
+	}
+	return result
+}
+
+// goLiteral returns s as a Go number or bool literal when it is one, and as a
+// quoted string otherwise.
+func goLiteral(s string) string {
+	if numberLiteral.MatchString(s) || s == "true" || s == "false" {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+var numberLiteral = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// intLiteral matches the values Min and Max take, as the validator only
+// compares them as ints.
+var intLiteral = regexp.MustCompile(`^-?\d+$`)
+
+func timeExpr(date string) string {
+	if date == "now" {
+		return "time.Now()"
+	}
+	t, _ := time.Parse(time.DateOnly, date)
+	return fmt.Sprintf("time.Date(%d, time.%s, %d, 0, 0, 0, 0, time.UTC)", t.Year(), t.Month(), t.Day())
+}
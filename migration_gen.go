@@ -4,13 +4,13 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/lemmego/fsys"
-
 	"github.com/charmbracelet/huh"
 	"github.com/gertd/go-pluralize"
 	"github.com/spf13/cobra"
@@ -26,33 +26,230 @@ var migrationFieldTypes = []string{
 type MigrationField struct {
 	Name               string
 	Type               string
+	Length             int // Length of string columns; 255 when zero
 	Nullable           bool
 	Unique             bool
 	Primary            bool
 	ForeignConstrained bool
+	ForeignTable       string // Referenced table; guessed from Name when empty
+	ForeignColumn      string // Referenced column; id when empty
+	OnDelete           string // e.g. CASCADE
+}
+
+// MigrationMode is the kind of schema change a migration makes.
+type MigrationMode string
+
+const (
+	MigrationCreate        MigrationMode = "create"
+	MigrationAddColumns    MigrationMode = "add_columns"
+	MigrationDropColumns   MigrationMode = "drop_columns"
+	MigrationRenameColumns MigrationMode = "rename_columns"
+	MigrationAddIndex      MigrationMode = "add_index"
+	MigrationDropIndex     MigrationMode = "drop_index"
+	MigrationDropTable     MigrationMode = "drop_table"
+	MigrationRaw           MigrationMode = "raw"
+)
+
+// migrationNamePatterns infer the mode and table of a migration from its
+// name, e.g. add_status_to_orders_table adds columns to orders. They are
+// tried in order, so the index patterns come before the column ones.
+var migrationNamePatterns = []struct {
+	mode    MigrationMode
+	pattern *regexp.Regexp
+}{
+	{MigrationCreate, regexp.MustCompile(`^create_(\w+)_table$`)},
+	{MigrationAddIndex, regexp.MustCompile(`^add_(?:\w+_)?index_to_(\w+)_table$`)},
+	{MigrationAddColumns, regexp.MustCompile(`^add_\w+?_to_(\w+)_table$`)},
+	{MigrationDropIndex, regexp.MustCompile(`^(?:drop|remove)_(?:\w+_)?index_from_(\w+)_table$`)},
+	{MigrationDropColumns, regexp.MustCompile(`^(?:drop|remove)_\w+?_from_(\w+)_table$`)},
+	{MigrationRenameColumns, regexp.MustCompile(`^rename_\w+?_(?:in|on)_(\w+)_table$`)},
+	{MigrationDropTable, regexp.MustCompile(`^drop_(\w+)_table$`)},
+}
+
+// ColumnRename renames the column From to To.
+type ColumnRename struct {
+	From string
+	To   string
 }
 
 type MigrationConfig struct {
+	Name           string // File and function name; create_<table>_table when empty
+	Mode           MigrationMode
 	TableName      string
 	Fields         []*MigrationField
+	Renames        []ColumnRename
+	IndexColumns   []string
+	UniqueIndex    bool
 	PrimaryColumns []string
 	UniqueColumns  [][]string
 	ForeignColumns [][]string
+	Indexes        [][]string
 	Timestamps     bool
 }
 
 type MigrationGenerator struct {
 	name      string
+	mode      MigrationMode
 	tableName string
 	fields    []*MigrationField
 	version   string
 
+	renames        []ColumnRename
+	indexColumns   []string
+	uniqueIndex    bool
 	primaryColumns []string
 	uniqueColumns  [][]string
 	foreignColumns [][]string
+	indexes        [][]string
 	Timestamps     bool
 }
 
+// migrationStep is the schema change made by one direction of a migration.
+type migrationStep struct {
+	Mode           MigrationMode
+	TableName      string
+	Fields         []*MigrationField
+	Renames        []ColumnRename
+	IndexColumns   []string
+	IndexName      string
+	UniqueIndex    bool
+	PrimaryColumns []string
+	UniqueColumns  [][]string
+	ForeignColumns [][]string
+	Indexes        [][]string
+	Note           string // Comment for raw steps
+}
+
+// inferMigration returns the mode and table encoded in a migration name. The
+// last result is false when the name follows none of the known patterns.
+func inferMigration(name string) (MigrationMode, string, bool) {
+	for _, p := range migrationNamePatterns {
+		if m := p.pattern.FindStringSubmatch(name); m != nil {
+			return p.mode, m[1], true
+		}
+	}
+	return "", "", false
+}
+
+// ParseMigrationArgs builds the configuration of a migration from its name
+// and the remaining command-line arguments. Names that follow none of the
+// patterns in migrationNamePatterns are taken as the table to create. What
+// the arguments are depends on the mode:
+//
+//   - create, add_columns, drop_columns, drop_table: field specs, see
+//     ParseMigrationFields. Dropped columns need their type so that the
+//     Down function can add them back.
+//   - rename_columns: old:new pairs.
+//   - add_index, drop_index: the indexed columns.
+//   - raw: nothing.
+func ParseMigrationArgs(name string, args []string, raw bool, uniqueIndex bool) (*MigrationConfig, error) {
+	if err := SnakeCase(name); err != nil {
+		return nil, err
+	}
+
+	if raw {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("a raw migration takes no columns")
+		}
+		return &MigrationConfig{Name: name, Mode: MigrationRaw}, nil
+	}
+
+	mode, table, ok := inferMigration(name)
+	if !ok {
+		mode, table, name = MigrationCreate, name, fmt.Sprintf("create_%s_table", name)
+	}
+
+	if uniqueIndex && mode != MigrationAddIndex && mode != MigrationDropIndex {
+		return nil, fmt.Errorf("--unique only applies to index migrations")
+	}
+
+	mc := &MigrationConfig{Name: name, Mode: mode, TableName: table, UniqueIndex: uniqueIndex}
+
+	switch mode {
+	case MigrationRenameColumns:
+		for _, arg := range args {
+			from, to, ok := strings.Cut(arg, ":")
+			if !ok || SnakeCase(from) != nil || SnakeCase(to) != nil {
+				return nil, fmt.Errorf("invalid rename %q: expected old_name:new_name", arg)
+			}
+			mc.Renames = append(mc.Renames, ColumnRename{from, to})
+		}
+	case MigrationAddIndex, MigrationDropIndex:
+		for _, arg := range args {
+			if err := SnakeCase(arg); err != nil {
+				return nil, fmt.Errorf("invalid column %q: %w", arg, err)
+			}
+		}
+		mc.IndexColumns = args
+	default:
+		fields, err := ParseMigrationFields(args)
+		if err != nil {
+			return nil, err
+		}
+		mc.Fields = fields
+	}
+
+	if len(args) == 0 {
+		switch mode {
+		case MigrationAddColumns, MigrationDropColumns:
+			return nil, fmt.Errorf("list the columns of %s, e.g. status:string", name)
+		case MigrationRenameColumns:
+			return nil, fmt.Errorf("list the columns to rename in %s, e.g. name:full_name", name)
+		case MigrationAddIndex, MigrationDropIndex:
+			return nil, fmt.Errorf("list the indexed columns of %s", name)
+		}
+	}
+
+	return mc, nil
+}
+
+// indexName returns the name the migration package gives an index or unique
+// key on columns, so that a later migration can drop it.
+func indexName(table string, columns []string, unique bool) string {
+	if unique {
+		if len(columns) == 1 {
+			return columns[0] + "_unique"
+		}
+		return table + "_" + strings.Join(columns, "_") + "_unique"
+	}
+	if len(columns) == 1 {
+		return table + "_" + columns[0] + "_index"
+	}
+	return strings.Join(columns, "_") + "_index"
+}
+
+// ParseMigrationFields converts command-line field specs such as
+// "title:string:unique" or "user_id:unsignedBigInt:foreign" into migration
+// fields. UI types like textarea or integer are converted through UiDbTypeMap.
+func ParseMigrationFields(specs []string) ([]*MigrationField, error) {
+	parsed, err := ParseFieldSpecs(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []*MigrationField
+	for _, spec := range parsed {
+		if dbType, ok := UiDbTypeMap[spec.Type]; ok && !slices.Contains(migrationFieldTypes, spec.Type) {
+			spec.Type = dbType
+		}
+		if !slices.Contains(migrationFieldTypes, spec.Type) {
+			return nil, fmt.Errorf("field %q: unknown type %q (expected one of: %s)", spec.Name, spec.Type, strings.Join(migrationFieldTypes, ", "))
+		}
+		if err := spec.checkModifiers("nullable", "unique", "primary", "foreign"); err != nil {
+			return nil, err
+		}
+		fields = append(fields, &MigrationField{
+			Name:               spec.Name,
+			Type:               spec.Type,
+			Nullable:           spec.Has("nullable"),
+			Unique:             spec.Has("unique"),
+			Primary:            spec.Has("primary"),
+			ForeignConstrained: spec.Has("foreign"),
+		})
+	}
+	return fields, nil
+}
+
 func NewMigrationGenerator(mc *MigrationConfig) *MigrationGenerator {
 	version := time.Now().Format("20060102150405")
 	if mc.Timestamps {
@@ -63,16 +260,81 @@ func NewMigrationGenerator(mc *MigrationConfig) *MigrationGenerator {
 		}
 		mc.Fields = append(mc.Fields, timeStampFields...)
 	}
+	mode := mc.Mode
+	if mode == "" {
+		mode = MigrationCreate
+	}
+	name := mc.Name
+	if name == "" {
+		name = fmt.Sprintf("create_%s_table", mc.TableName)
+	}
 	return &MigrationGenerator{
-		fmt.Sprintf("create_%s_table", mc.TableName),
-		mc.TableName,
-		mc.Fields,
-		version,
-		mc.PrimaryColumns,
-		mc.UniqueColumns,
-		mc.ForeignColumns,
-		mc.Timestamps,
+		name:           name,
+		mode:           mode,
+		tableName:      mc.TableName,
+		fields:         mc.Fields,
+		version:        version,
+		renames:        mc.Renames,
+		indexColumns:   mc.IndexColumns,
+		uniqueIndex:    mc.UniqueIndex,
+		primaryColumns: mc.PrimaryColumns,
+		uniqueColumns:  mc.UniqueColumns,
+		foreignColumns: mc.ForeignColumns,
+		indexes:        mc.Indexes,
+		Timestamps:     mc.Timestamps,
+	}
+}
+
+// up returns the schema change the migration makes.
+func (mg *MigrationGenerator) up() *migrationStep {
+	step := &migrationStep{
+		Mode:         mg.mode,
+		TableName:    mg.tableName,
+		Fields:       mg.fields,
+		Renames:      mg.renames,
+		IndexColumns: mg.indexColumns,
+		IndexName:    indexName(mg.tableName, mg.indexColumns, mg.uniqueIndex),
+		UniqueIndex:  mg.uniqueIndex,
+	}
+	if mg.mode == MigrationCreate {
+		step.PrimaryColumns = mg.primaryColumns
+		step.UniqueColumns = mg.uniqueColumns
+		step.ForeignColumns = mg.foreignColumns
+		step.Indexes = mg.indexes
 	}
+	return step
+}
+
+// down returns the schema change that undoes up.
+func (mg *MigrationGenerator) down() *migrationStep {
+	up := mg.up()
+	down := *up
+	down.PrimaryColumns, down.UniqueColumns, down.ForeignColumns, down.Indexes = nil, nil, nil, nil
+
+	switch up.Mode {
+	case MigrationCreate:
+		down.Mode = MigrationDropTable
+	case MigrationAddColumns:
+		down.Mode = MigrationDropColumns
+	case MigrationDropColumns:
+		down.Mode = MigrationAddColumns
+	case MigrationRenameColumns:
+		down.Renames = nil
+		for _, r := range up.Renames {
+			down.Renames = append(down.Renames, ColumnRename{r.To, r.From})
+		}
+	case MigrationAddIndex:
+		down.Mode = MigrationDropIndex
+	case MigrationDropIndex:
+		down.Mode = MigrationAddIndex
+	case MigrationDropTable:
+		down.Mode = MigrationCreate
+		if len(up.Fields) == 0 {
+			down.Mode = MigrationRaw
+			down.Note = fmt.Sprintf("Recreate the %s table here.", up.TableName)
+		}
+	}
+	return &down
 }
 
 func (mg *MigrationGenerator) BumpVersion() *MigrationGenerator {
@@ -81,6 +343,16 @@ func (mg *MigrationGenerator) BumpVersion() *MigrationGenerator {
 	return mg
 }
 
+// Existing returns the path of a migration with the same name generated
+// before, whatever its version, or an empty string if there is none.
+func (mg *MigrationGenerator) Existing() string {
+	matches, _ := filepath.Glob(filepath.Join(mg.GetPackagePath(), "*_"+mg.name+".go"))
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
 func (mg *MigrationGenerator) GetPackagePath() string {
 	path := "internal/migrations"
 	if dir := os.Getenv("MIGRATIONS_DIR"); dir != "" {
@@ -90,11 +362,10 @@ func (mg *MigrationGenerator) GetPackagePath() string {
 }
 
 func (mg *MigrationGenerator) GetStub() string {
-	return migrationStub
+	return loadStub("migration.txt")
 }
 
-func (mg *MigrationGenerator) Generate(appendable ...[]byte) error {
-	fs := fsys.NewLocalStorage("")
+func (mg *MigrationGenerator) Generate() error {
 	parts := strings.Split(mg.GetPackagePath(), "/")
 	packageName := mg.GetPackagePath()
 
@@ -112,10 +383,8 @@ func (mg *MigrationGenerator) Generate(appendable ...[]byte) error {
 		"UniqueColumns":  mg.uniqueColumns,
 		"ForeignColumns": mg.foreignColumns,
 		"Timestamps":     mg.Timestamps,
-	}
-
-	if len(appendable) > 0 {
-		tmplData["Appendable"] = string(appendable[0])
+		"Up":             mg.up(),
+		"Down":           mg.down(),
 	}
 
 	output, err := ParseTemplate(tmplData, mg.GetStub(), CommonFuncs)
@@ -124,26 +393,41 @@ func (mg *MigrationGenerator) Generate(appendable ...[]byte) error {
 		return err
 	}
 
-	err = fs.Write(mg.GetPackagePath()+"/"+mg.version+"_"+mg.name+".go", []byte(output))
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return writeGeneratedGoFile("migration.txt", mg.GetPackagePath()+"/"+mg.version+"_"+mg.name+".go", []byte(output))
 }
 
 func (mg *MigrationGenerator) Command() *cobra.Command {
 	return migrationCmd
 }
 
+var migrationTimestamps, migrationRaw, migrationUniqueIndex bool
+
+func init() {
+	migrationCmd.Flags().BoolVar(&migrationTimestamps, "timestamps", false, "Add created_at, updated_at and deleted_at columns")
+	migrationCmd.Flags().BoolVar(&migrationRaw, "raw", false, "Generate an empty migration to fill with raw SQL")
+	migrationCmd.Flags().BoolVar(&migrationUniqueIndex, "unique", false, "Make the index of an add_*index*/drop_*index* migration a unique key")
+}
+
 var migrationCmd = &cobra.Command{
-	Use:   "migration",
+	Use:   "migration [name] [field:type[:modifier]...]",
 	Short: "Generate a simple migration file",
-	Long:  `Generate a simple migration file`,
+	Long: `Generate a simple migration file.
+
+The kind of migration is inferred from its name:
+
+  create_orders_table                  create a table (a bare table name works too)
+  add_status_to_orders_table           add columns:    status:string
+  drop_status_from_orders_table        drop columns:   status:string
+  rename_name_in_users_table           rename columns: name:full_name
+  add_email_index_to_users_table       add an index:   email (--unique for a unique key)
+  drop_email_index_from_users_table    drop an index:  email
+  drop_orders_table                    drop a table, optionally with its fields for the Down function
+
+Use --raw for an empty migration to write SQL in by hand.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var tableName string
 		var fields []*MigrationField
+		var mc *MigrationConfig
 
 		primaryColumns := []string{}
 		uniqueColumns := []string{}
@@ -172,6 +456,9 @@ var migrationCmd = &cobra.Command{
 			if err != nil {
 				return
 			}
+			if mode, table, ok := inferMigration(tableName); ok && mode == MigrationCreate {
+				tableName = table
+			}
 
 			for {
 				var fieldName, fieldType string
@@ -252,18 +539,26 @@ var migrationCmd = &cobra.Command{
 			if err != nil {
 				return
 			}
+
+			mc = &MigrationConfig{
+				TableName:      tableName,
+				Fields:         fields,
+				PrimaryColumns: selectedPrimaryColumns,
+				UniqueColumns:  [][]string{selectedUniqueColumns},
+				ForeignColumns: [][]string{selectedForeignColumns},
+				Timestamps:     timestamps,
+			}
 		} else {
-			tableName = args[0]
+			var err error
+			mc, err = ParseMigrationArgs(args[0], args[1:], migrationRaw, migrationUniqueIndex)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			mc.Timestamps = migrationTimestamps
 		}
 
-		mg := NewMigrationGenerator(&MigrationConfig{
-			TableName:      tableName,
-			Fields:         fields,
-			PrimaryColumns: selectedPrimaryColumns,
-			UniqueColumns:  [][]string{selectedUniqueColumns},
-			ForeignColumns: [][]string{selectedForeignColumns},
-			Timestamps:     timestamps,
-		})
+		mg := NewMigrationGenerator(mc)
 		err := mg.Generate()
 		if err != nil {
 			fmt.Println(err)
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInferMigration(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  MigrationMode
+		table string
+	}{
+		{"create_orders_table", MigrationCreate, "orders"},
+		{"create_order_items_table", MigrationCreate, "order_items"},
+		{"add_status_to_orders_table", MigrationAddColumns, "orders"},
+		{"add_paid_at_to_order_items_table", MigrationAddColumns, "order_items"},
+		{"add_email_index_to_users_table", MigrationAddIndex, "users"},
+		{"add_index_to_users_table", MigrationAddIndex, "users"},
+		{"drop_status_from_orders_table", MigrationDropColumns, "orders"},
+		{"remove_status_from_orders_table", MigrationDropColumns, "orders"},
+		{"drop_email_index_from_users_table", MigrationDropIndex, "users"},
+		{"rename_name_in_users_table", MigrationRenameColumns, "users"},
+		{"drop_orders_table", MigrationDropTable, "orders"},
+	}
+
+	for _, tt := range tests {
+		mode, table, ok := inferMigration(tt.name)
+		if !ok || mode != tt.mode || table != tt.table {
+			t.Errorf("inferMigration(%q) = %s, %s, %v, want %s, %s", tt.name, mode, table, ok, tt.mode, tt.table)
+		}
+	}
+
+	if _, _, ok := inferMigration("orders"); ok {
+		t.Error("expected a bare table name not to match")
+	}
+}
+
+func TestParseMigrationArgs(t *testing.T) {
+	mc, err := ParseMigrationArgs("orders", []string{"title:string"}, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mc.Mode != MigrationCreate || mc.Name != "create_orders_table" || mc.TableName != "orders" {
+		t.Errorf("unexpected config for a bare table name: %+v", mc)
+	}
+
+	mc, err = ParseMigrationArgs("rename_name_in_users_table", []string{"name:full_name"}, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mc.Renames) != 1 || mc.Renames[0] != (ColumnRename{"name", "full_name"}) {
+		t.Errorf("unexpected renames %v", mc.Renames)
+	}
+
+	invalid := []struct {
+		name   string
+		args   []string
+		raw    bool
+		unique bool
+	}{
+		{"add_status_to_orders_table", nil, false, false},
+		{"rename_name_in_users_table", []string{"name"}, false, false},
+		{"add_email_index_to_users_table", nil, false, false},
+		{"create_users_table", nil, false, true},
+		{"backfill_slugs", []string{"slug:string"}, true, false},
+	}
+	for _, tt := range invalid {
+		if _, err := ParseMigrationArgs(tt.name, tt.args, tt.raw, tt.unique); err == nil {
+			t.Errorf("expected an error for %s %v", tt.name, tt.args)
+		}
+	}
+}
+
+func TestIndexName(t *testing.T) {
+	tests := []struct {
+		columns []string
+		unique  bool
+		want    string
+	}{
+		{[]string{"email"}, false, "users_email_index"},
+		{[]string{"first_name", "last_name"}, false, "first_name_last_name_index"},
+		{[]string{"email"}, true, "email_unique"},
+		{[]string{"first_name", "last_name"}, true, "users_first_name_last_name_unique"},
+	}
+	for _, tt := range tests {
+		if got := indexName("users", tt.columns, tt.unique); got != tt.want {
+			t.Errorf("indexName(%v, %v) = %s, want %s", tt.columns, tt.unique, got, tt.want)
+		}
+	}
+}
+
+func TestMigrationDownIsInverse(t *testing.T) {
+	status := []*MigrationField{{Name: "status", Type: "string"}}
+	tests := []struct {
+		config *MigrationConfig
+		want   MigrationMode
+	}{
+		{&MigrationConfig{TableName: "orders", Fields: status}, MigrationDropTable},
+		{&MigrationConfig{Mode: MigrationAddColumns, TableName: "orders", Fields: status}, MigrationDropColumns},
+		{&MigrationConfig{Mode: MigrationDropColumns, TableName: "orders", Fields: status}, MigrationAddColumns},
+		{&MigrationConfig{Mode: MigrationAddIndex, TableName: "orders", IndexColumns: []string{"status"}}, MigrationDropIndex},
+		{&MigrationConfig{Mode: MigrationDropIndex, TableName: "orders", IndexColumns: []string{"status"}}, MigrationAddIndex},
+		{&MigrationConfig{Mode: MigrationDropTable, TableName: "orders", Fields: status}, MigrationCreate},
+		{&MigrationConfig{Mode: MigrationDropTable, TableName: "orders"}, MigrationRaw},
+		{&MigrationConfig{Mode: MigrationRaw}, MigrationRaw},
+	}
+	for _, tt := range tests {
+		if got := NewMigrationGenerator(tt.config).down().Mode; got != tt.want {
+			t.Errorf("down of %s = %s, want %s", tt.config.Mode, got, tt.want)
+		}
+	}
+
+	rename := NewMigrationGenerator(&MigrationConfig{
+		Mode:      MigrationRenameColumns,
+		TableName: "users",
+		Renames:   []ColumnRename{{"name", "full_name"}},
+	})
+	if got := rename.down().Renames; len(got) != 1 || got[0] != (ColumnRename{"full_name", "name"}) {
+		t.Errorf("unexpected inverse renames %v", got)
+	}
+}
+
+func TestMigrationGenerateAddColumns(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	mc, err := ParseMigrationArgs("add_status_to_orders_table", []string{"status:string:nullable"}, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg := NewMigrationGenerator(mc)
+	if err := mg.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.ReadFile(filepath.Join("internal", "migrations", mg.version+"_add_status_to_orders_table.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`migration.Alter("orders", func(t *migration.Table) {` + "\n\t\t" + `t.String("status", 255).Nullable()`,
+		`migration.Alter("orders", func(t *migration.Table) {` + "\n\t\t" + `t.DropColumn("status")`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected generated migration to contain:\n%s\ngot:\n%s", want, out)
+		}
+	}
+}
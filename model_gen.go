@@ -4,11 +4,12 @@ import (
 	_ "embed"
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
-	"github.com/lemmego/fsys"
-
 	"github.com/charmbracelet/huh"
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
 	"github.com/spf13/cobra"
 )
 
@@ -33,20 +34,19 @@ var CommonModelFields = []*ModelField{
 		Required: true,
 		Primary:  true,
 	},
+	// The timestamps are left nullable like the columns migrations add for
+	// them; deleted_at must be, as soft deletes treat NULL as not deleted.
 	{
-		Name:     "created_at",
-		Type:     "time.Time",
-		Required: true,
+		Name: "created_at",
+		Type: "time.Time",
 	},
 	{
-		Name:     "updated_at",
-		Type:     "time.Time",
-		Required: true,
+		Name: "updated_at",
+		Type: "time.Time",
 	},
 	{
-		Name:     "deleted_at",
-		Type:     "time.Time",
-		Required: true,
+		Name: "deleted_at",
+		Type: "time.Time",
 	},
 }
 
@@ -60,23 +60,36 @@ const (
 type ModelField struct {
 	Name               string
 	Type               string
+	Length             int // Column length of string fields; the ORM's default when zero
 	Required           bool
 	Unique             bool
 	Primary            bool
 	ForeignConstrained bool
 	Relation           string
+	RelatedModel       string // snake_case name of the related model, derived from Name when empty
 }
 
 type ModelConfig struct {
 	Name   string
+	ORM    OrmChoice
 	Fields []*ModelField
 }
 
 type ModelGenerator struct {
 	name   string
+	orm    OrmChoice
 	fields []*ModelField
 }
 
+// ModelStructField is a single field of the generated struct. Relation fields
+// expand to one or two struct fields.
+type ModelStructField struct {
+	Name string // snake_case, rendered with toCamel
+	Type string
+	JSON string // value of the json tag
+	Tag  string // ORM tag, e.g. gorm:"foreignKey:AuthorID"
+}
+
 type DBTag struct {
 	Name     string
 	Argument string
@@ -97,8 +110,9 @@ func (mtb *DBTagBuilder) Add(name, argument string) *DBTagBuilder {
 }
 
 func (mtb *DBTagBuilder) Build() string {
-	// Build the tag string in this format: gorm:"tagName1:tagArgument1,tagName2:tagArgument2".
-	// If the argument is empty, it's omitted: gorm:"tagName1,tagName2".
+	// Build the tag string in this format: bun:"tagName1:tagArgument1,tagName2:tagArgument2".
+	// If the argument is empty, it's omitted: bun:"tagName1,tagName2".
+	// GORM separates its tags with semicolons instead: gorm:"tagName1;tagName2".
 	var tagStrs []string
 	for _, t := range mtb.tags {
 		if t.Argument != "" {
@@ -110,11 +124,353 @@ func (mtb *DBTagBuilder) Build() string {
 	if len(tagStrs) == 0 {
 		return ""
 	}
-	return fmt.Sprintf("%s:", mtb.driverName) + "\"" + strings.Join(tagStrs, ",") + "\""
+	sep := ","
+	if mtb.driverName == string(OrmGORM) {
+		sep = ";"
+	}
+	return fmt.Sprintf("%s:", mtb.driverName) + "\"" + strings.Join(tagStrs, sep) + "\""
+}
+
+// ParseModelFields converts command-line field specs such as
+// "author:relation:many_to_one" or "title:string:required" into model fields.
+// Relations accept "model=<name>" for the related model and, for
+// many_to_one, "constrained" for a cascading foreign key.
+// UI types like text or integer are converted through UiDataTypeMap.
+func ParseModelFields(specs []string) ([]*ModelField, error) {
+	parsed, err := ParseFieldSpecs(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []*ModelField
+	for _, spec := range parsed {
+		if slices.ContainsFunc(CommonModelFields, func(f *ModelField) bool { return f.Name == spec.Name }) {
+			return nil, fmt.Errorf("field %q is provided by default", spec.Name)
+		}
+
+		field := &ModelField{Name: spec.Name, Type: spec.Type}
+		if goType, ok := UiDataTypeMap[spec.Type]; ok {
+			field.Type = goType
+		}
+
+		if spec.Type == "relation" {
+			if len(spec.Modifiers) == 0 || !slices.Contains(modelRelations, spec.Modifiers[0]) {
+				return nil, fmt.Errorf("field %q: a relation needs one of: %s", spec.Name, strings.Join(modelRelations, ", "))
+			}
+			field.Relation = spec.Modifiers[0]
+			rest := &FieldSpec{Name: spec.Name, Modifiers: spec.Modifiers[1:]}
+			if err := rest.checkModifiers("model", "constrained"); err != nil {
+				return nil, err
+			}
+			if related, ok := rest.Value("model"); ok {
+				if err := SnakeCase(related); err != nil {
+					return nil, fmt.Errorf("field %q: model: %w", spec.Name, err)
+				}
+				field.RelatedModel = related
+			}
+			field.ForeignConstrained = rest.Has("constrained")
+			if field.ForeignConstrained && field.Relation != RelationManyToOne {
+				return nil, fmt.Errorf("field %q: only many_to_one relations own a foreign key to constrain", spec.Name)
+			}
+		} else {
+			if err := spec.checkModifiers("required", "unique", "primary"); err != nil {
+				return nil, err
+			}
+			field.Required = spec.Has("required")
+			field.Unique = spec.Has("unique")
+			field.Primary = spec.Has("primary")
+		}
+
+		fields = append(fields, field)
+	}
+	return fields, nil
 }
 
 func NewModelGenerator(mc *ModelConfig) *ModelGenerator {
-	return &ModelGenerator{mc.Name, mc.Fields}
+	orm := mc.ORM
+	if orm == "" {
+		orm = OrmGORM
+	}
+	return &ModelGenerator{mc.Name, orm, mc.Fields}
+}
+
+// relatedModel returns the snake_case name of the model on the other side of
+// a relation field. Fields of to-many relations are named in plural form.
+func relatedModel(f *ModelField) string {
+	if f.RelatedModel != "" {
+		return f.RelatedModel
+	}
+	if f.Relation == RelationOneToMany || f.Relation == RelationManyToMany {
+		return pluralize.NewClient().Singular(f.Name)
+	}
+	return f.Name
+}
+
+// pivotTable returns the join table name of a many_to_many field: the two
+// model names in alphabetical order, e.g. post_tags for the tags of a post as
+// well as the posts of a tag, so that both sides of the relation share it.
+func (mg *ModelGenerator) pivotTable(f *ModelField) string {
+	first, second := pivotModels(strcase.ToSnake(mg.name), relatedModel(f))
+	return first + "_" + pluralize.NewClient().Plural(second)
+}
+
+// pivotModels returns the two models of a many_to_many relation in
+// alphabetical order.
+func pivotModels(a, b string) (string, string) {
+	if b < a {
+		return b, a
+	}
+	return a, b
+}
+
+// pivotSides returns the names the owner and the related model have in the
+// pivot table of a many_to_many relation: the model names, or the model name
+// and related_<name> when a model is related to itself, as in user_id and
+// related_user_id.
+func pivotSides(owner, related string) (string, string) {
+	if owner == related {
+		return owner, "related_" + related
+	}
+	return owner, related
+}
+
+// StructFields expands the configured fields into the fields of the struct.
+// A many_to_one relation adds a foreign key next to the association, the
+// other relations add the association only.
+func (mg *ModelGenerator) StructFields() []*ModelStructField {
+	strcase.ConfigureAcronym("id", "ID")
+	var fields []*ModelStructField
+
+	for _, f := range mg.fields {
+		if f.Name == "deleted_at" && f.Type == "time.Time" {
+			fields = append(fields, mg.softDeleteField(f))
+			continue
+		}
+		if f.Type != "relation" {
+			fields = append(fields, &ModelStructField{
+				Name: f.Name,
+				Type: f.Type,
+				JSON: strcase.ToSnake(f.Name),
+				Tag:  mg.columnTag(f),
+			})
+			continue
+		}
+
+		relatedType := strcase.ToCamel(relatedModel(f))
+
+		if f.Relation == RelationManyToOne {
+			// The foreign key carries the attributes given to the relation.
+			fk := &ModelField{Name: f.Name + "_id", Type: "uint64", Required: f.Required, Unique: f.Unique, Primary: f.Primary}
+			fields = append(fields, &ModelStructField{
+				Name: fk.Name,
+				Type: fk.Type,
+				JSON: fk.Name,
+				Tag:  mg.columnTag(fk),
+			})
+		}
+
+		goType := "*" + relatedType
+		if f.Relation == RelationOneToMany || f.Relation == RelationManyToMany {
+			goType = "[]*" + relatedType
+		}
+
+		fields = append(fields, &ModelStructField{
+			Name: f.Name,
+			Type: goType,
+			JSON: strcase.ToSnake(f.Name) + ",omitempty",
+			Tag:  mg.relationTag(f),
+		})
+	}
+
+	return fields
+}
+
+// columnTag builds the ORM tag of a plain column field from its Length and
+// its Primary, Unique and Required flags, e.g. gorm:"size:191;uniqueIndex;not null"
+// or bun:",type:varchar(191),unique,notnull". Fields without any get no tag
+// and rely on the ORM's default column naming.
+func (mg *ModelGenerator) columnTag(f *ModelField) string {
+	tb := NewDBTagBuilder(nil, string(mg.orm))
+
+	if mg.orm == OrmBun {
+		if f.Type == "time.Time" && (f.Name == "created_at" || f.Name == "updated_at") {
+			// Bun writes an unset time as 0001-01-01 unless it is nullzero,
+			// and the default stamps the row with the time of the insert.
+			return `bun:",nullzero,default:current_timestamp"`
+		}
+		if !f.Primary && !f.Unique && !f.Required && f.Length == 0 {
+			return ""
+		}
+		// The first bun option is the column name, left empty for the default.
+		tb.Add("", "")
+		if f.Length > 0 {
+			tb.Add("type", fmt.Sprintf("varchar(%d)", f.Length))
+		}
+		if f.Primary {
+			tb.Add("pk", "")
+			if f.Name == "id" {
+				tb.Add("autoincrement", "")
+			}
+		}
+		if f.Unique {
+			tb.Add("unique", "")
+		}
+		if f.Required && !f.Primary {
+			tb.Add("notnull", "")
+		}
+		return tb.Build()
+	}
+
+	if f.Length > 0 {
+		tb.Add("size", strconv.Itoa(f.Length))
+	}
+	if f.Primary {
+		tb.Add("primaryKey", "")
+	}
+	if f.Unique {
+		tb.Add("uniqueIndex", "")
+	}
+	if f.Required && !f.Primary {
+		tb.Add("not null", "")
+	}
+	return tb.Build()
+}
+
+// softDeleteField returns the deleted_at field the ORM soft deletes with:
+// gorm.DeletedAt for GORM, and a time.Time tagged soft_delete for bun, which
+// nullzero keeps NULL on rows that aren't deleted.
+func (mg *ModelGenerator) softDeleteField(f *ModelField) *ModelStructField {
+	field := &ModelStructField{Name: f.Name, Type: "gorm.DeletedAt", JSON: f.Name, Tag: `gorm:"index"`}
+	if mg.orm == OrmBun {
+		field.Type = "time.Time"
+		field.Tag = `bun:",soft_delete,nullzero"`
+	}
+	return field
+}
+
+// baseModel returns the embedded field bun models need to name their table.
+// GORM models embed nothing.
+func (mg *ModelGenerator) baseModel() string {
+	if mg.orm != OrmBun {
+		return ""
+	}
+	table := pluralize.NewClient().Plural(strcase.ToSnake(mg.name))
+	return fmt.Sprintf("bun.BaseModel `bun:\"table:%s\"`", table)
+}
+
+// imports returns the packages the generated struct refers to.
+func (mg *ModelGenerator) imports(fields []*ModelStructField) []string {
+	var imports []string
+	if slices.ContainsFunc(fields, func(f *ModelStructField) bool { return strings.Contains(f.Type, "time.") }) {
+		imports = append(imports, "time")
+	}
+	if slices.ContainsFunc(fields, func(f *ModelStructField) bool { return strings.HasPrefix(f.Type, "gorm.") }) {
+		imports = append(imports, "gorm.io/gorm")
+	}
+	if mg.orm == OrmBun {
+		imports = append(imports, "github.com/uptrace/bun")
+	}
+	return imports
+}
+
+// relationTag builds the ORM tag describing a relation field.
+func (mg *ModelGenerator) relationTag(f *ModelField) string {
+	owner := strcase.ToSnake(mg.name)
+	tb := NewDBTagBuilder(nil, string(mg.orm))
+
+	if mg.orm == OrmBun {
+		switch f.Relation {
+		case RelationManyToOne:
+			tb.Add("rel", "belongs-to").Add("join", f.Name+"_id=id")
+			if f.ForeignConstrained {
+				tb.Add("on_delete", "CASCADE")
+			}
+		case RelationOneToOne:
+			tb.Add("rel", "has-one").Add("join", "id="+owner+"_id")
+		case RelationOneToMany:
+			tb.Add("rel", "has-many").Add("join", "id="+owner+"_id")
+		case RelationManyToMany:
+			ownerSide, relatedSide := pivotSides(owner, relatedModel(f))
+			tb.Add("m2m", mg.pivotTable(f)).Add("join", strcase.ToCamel(ownerSide)+"="+strcase.ToCamel(relatedSide))
+		}
+		return tb.Build()
+	}
+
+	switch f.Relation {
+	case RelationManyToOne:
+		tb.Add("foreignKey", goName(f.Name+"_id"))
+		if f.ForeignConstrained {
+			tb.Add("constraint", "OnUpdate:CASCADE,OnDelete:CASCADE")
+		}
+	case RelationOneToOne, RelationOneToMany:
+		tb.Add("foreignKey", goName(owner+"_id"))
+	case RelationManyToMany:
+		tb.Add("many2many", mg.pivotTable(f))
+		if related := relatedModel(f); related == owner {
+			ownerSide, relatedSide := pivotSides(owner, related)
+			tb.Add("joinForeignKey", goName(ownerSide+"_id")).Add("joinReferences", goName(relatedSide+"_id"))
+		}
+	}
+	return tb.Build()
+}
+
+// generatePivots generates the join table migration of every many_to_many
+// field. Bun also needs a model for the join table to resolve the relation.
+func (mg *ModelGenerator) generatePivots() error {
+	pivots := 0
+	for _, f := range mg.fields {
+		if f.Relation != RelationManyToMany {
+			continue
+		}
+
+		first, second := pivotModels(strcase.ToSnake(mg.name), relatedModel(f))
+		firstSide, secondSide := pivotSides(first, second)
+		table := mg.pivotTable(f)
+
+		plural := pluralize.NewClient()
+		migration := NewMigrationGenerator(&MigrationConfig{
+			TableName: table,
+			Fields: []*MigrationField{
+				{Name: firstSide + "_id", Type: "unsignedBigInt", ForeignConstrained: true, ForeignTable: plural.Plural(first)},
+				{Name: secondSide + "_id", Type: "unsignedBigInt", ForeignConstrained: true, ForeignTable: plural.Plural(second)},
+			},
+			PrimaryColumns: []string{firstSide + "_id", secondSide + "_id"},
+		})
+
+		// The other side of the relation may have generated the table already.
+		if existing := migration.Existing(); existing != "" {
+			fmt.Printf("Pivot migration for %s already exists in %s.\n", table, existing)
+		} else {
+			// Keep migrations generated within the same second apart.
+			for range pivots {
+				migration.BumpVersion()
+			}
+			pivots++
+
+			if err := migration.Generate(); err != nil {
+				return fmt.Errorf("generating pivot migration for %s: %w", table, err)
+			}
+			fmt.Printf("Pivot migration for %s generated successfully.\n", table)
+		}
+
+		if mg.orm != OrmBun {
+			continue
+		}
+
+		pivot := NewModelGenerator(&ModelConfig{
+			Name: first + "_" + second,
+			ORM:  OrmBun,
+			Fields: []*ModelField{
+				{Name: firstSide, Type: "relation", Relation: RelationManyToOne, Primary: true, RelatedModel: first},
+				{Name: secondSide, Type: "relation", Relation: RelationManyToOne, Primary: true, RelatedModel: second},
+			},
+		})
+		if err := pivot.Generate(); err != nil {
+			return fmt.Errorf("generating pivot model for %s: %w", table, err)
+		}
+		fmt.Printf("Pivot model %s generated, register it with db.RegisterModel((*models.%s)(nil)).\n", pivot.name, strcase.ToCamel(pivot.name))
+	}
+	return nil
 }
 
 func (mg *ModelGenerator) GetPackagePath() string {
@@ -122,11 +478,10 @@ func (mg *ModelGenerator) GetPackagePath() string {
 }
 
 func (mg *ModelGenerator) GetStub() string {
-	return modelStub
+	return loadStub("model.txt")
 }
 
-func (mg *ModelGenerator) Generate(appendable ...[]byte) error {
-	fs := fsys.NewLocalStorage("")
+func (mg *ModelGenerator) Generate() error {
 	parts := strings.Split(mg.GetPackagePath(), "/")
 	packageName := mg.GetPackagePath()
 
@@ -134,14 +489,13 @@ func (mg *ModelGenerator) Generate(appendable ...[]byte) error {
 		packageName = parts[len(parts)-1]
 	}
 
+	fields := mg.StructFields()
 	tmplData := map[string]interface{}{
 		"PackageName": packageName,
 		"ModelName":   mg.name,
-		"Fields":      mg.fields,
-	}
-
-	if len(appendable) > 0 {
-		tmplData["Appendable"] = string(appendable[0])
+		"Imports":     mg.imports(fields),
+		"BaseModel":   mg.baseModel(),
+		"Fields":      fields,
 	}
 
 	output, err := ParseTemplate(tmplData, mg.GetStub(), CommonFuncs)
@@ -150,22 +504,11 @@ func (mg *ModelGenerator) Generate(appendable ...[]byte) error {
 		return err
 	}
 
-	if exists, _ := fs.Exists(mg.GetPackagePath()); exists {
-		err = fs.Write(mg.GetPackagePath()+"/"+mg.name+".go", []byte(output))
-
-		if err != nil {
-			return err
-		}
-	} else {
-		fs.CreateDirectory(mg.GetPackagePath())
-		err = fs.Write(mg.GetPackagePath()+"/"+mg.name+".go", []byte(output))
-
-		if err != nil {
-			return err
-		}
+	if err := writeGeneratedGoFile("model.txt", mg.GetPackagePath()+"/"+mg.name+".go", []byte(output)); err != nil {
+		return err
 	}
 
-	return nil
+	return mg.generatePivots()
 }
 
 func (mg *ModelGenerator) Command() *cobra.Command {
@@ -173,7 +516,7 @@ func (mg *ModelGenerator) Command() *cobra.Command {
 }
 
 var modelCmd = &cobra.Command{
-	Use:   "model",
+	Use:   "model [name] [field:type[:modifier]...]",
 	Short: "Generate a db model",
 	Long:  `Generate a db model`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -202,7 +545,8 @@ var modelCmd = &cobra.Command{
 			}
 
 			for {
-				var fieldName, fieldType, relation string
+				var fieldName, fieldType, relation, related string
+				var constrained bool
 				const required = "Required"
 				const unique = "Unique"
 				const primary = "Primary"
@@ -256,6 +600,26 @@ var modelCmd = &cobra.Command{
 					if err != nil {
 						return
 					}
+
+					related = fieldName
+					if relation == RelationOneToMany || relation == RelationManyToMany {
+						related = pluralize.NewClient().Singular(fieldName)
+					}
+					relatedFields := []huh.Field{
+						huh.NewInput().
+							Title("Enter the related model name in snake_case and singular form").
+							Value(&related).
+							Validate(SnakeCase),
+					}
+					if relation == RelationManyToOne {
+						relatedFields = append(relatedFields, huh.NewConfirm().
+							Title(fmt.Sprintf("Cascade updates and deletes through %s_id?", fieldName)).
+							Value(&constrained))
+					}
+					err = huh.NewForm(huh.NewGroup(relatedFields...)).Run()
+					if err != nil {
+						return
+					}
 				}
 
 				if fieldType == "custom" {
@@ -290,20 +654,28 @@ var modelCmd = &cobra.Command{
 				fields = append(
 					fields,
 					&ModelField{
-						Name:     fieldName,
-						Type:     fieldType,
-						Required: slices.Contains(selectedAttrs, required),
-						Unique:   slices.Contains(selectedAttrs, unique),
-						Primary:  slices.Contains(selectedAttrs, primary),
-						Relation: relation,
+						Name:               fieldName,
+						Type:               fieldType,
+						Required:           slices.Contains(selectedAttrs, required),
+						Unique:             slices.Contains(selectedAttrs, unique),
+						Primary:            slices.Contains(selectedAttrs, primary),
+						Relation:           relation,
+						RelatedModel:       related,
+						ForeignConstrained: constrained,
 					},
 				)
 			}
 		} else {
 			modelName = args[0]
+			specFields, err := ParseModelFields(args[1:])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			fields = append(fields, specFields...)
 		}
 
-		mg := NewModelGenerator(&ModelConfig{Name: modelName, Fields: fields})
+		mg := NewModelGenerator(&ModelConfig{Name: modelName, ORM: detectProjectORM(currentProjectManifest()), Fields: fields})
 		err := mg.Generate()
 		if err != nil {
 			fmt.Println(err)
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDBTagBuilder(t *testing.T) {
+	gorm := NewDBTagBuilder(nil, "gorm").Add("foreignKey", "AuthorID").Add("not null", "").Build()
+	if gorm != `gorm:"foreignKey:AuthorID;not null"` {
+		t.Errorf("unexpected gorm tag %s", gorm)
+	}
+	bun := NewDBTagBuilder(nil, "bun").Add("rel", "belongs-to").Add("join", "author_id=id").Build()
+	if bun != `bun:"rel:belongs-to,join:author_id=id"` {
+		t.Errorf("unexpected bun tag %s", bun)
+	}
+	if empty := NewDBTagBuilder(nil, "bun").Build(); empty != "" {
+		t.Errorf("expected no tag without entries, got %s", empty)
+	}
+}
+
+func relationFields() []*ModelField {
+	return []*ModelField{
+		{Name: "author", Type: "relation", Relation: RelationManyToOne, RelatedModel: "user", ForeignConstrained: true},
+		{Name: "profile", Type: "relation", Relation: RelationOneToOne},
+		{Name: "comments", Type: "relation", Relation: RelationOneToMany},
+		{Name: "tags", Type: "relation", Relation: RelationManyToMany},
+	}
+}
+
+func TestModelStructFieldsGORMRelations(t *testing.T) {
+	mg := NewModelGenerator(&ModelConfig{Name: "blog_post", ORM: OrmGORM, Fields: relationFields()})
+	fields := mg.StructFields()
+
+	want := []ModelStructField{
+		{Name: "author_id", Type: "uint64", JSON: "author_id"},
+		{Name: "author", Type: "*User", JSON: "author,omitempty", Tag: `gorm:"foreignKey:AuthorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`},
+		{Name: "profile", Type: "*Profile", JSON: "profile,omitempty", Tag: `gorm:"foreignKey:BlogPostID"`},
+		{Name: "comments", Type: "[]*Comment", JSON: "comments,omitempty", Tag: `gorm:"foreignKey:BlogPostID"`},
+		{Name: "tags", Type: "[]*Tag", JSON: "tags,omitempty", Tag: `gorm:"many2many:blog_post_tags"`},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %d struct fields, got %d", len(want), len(fields))
+	}
+	for i, w := range want {
+		got := fields[i]
+		if got.Name != w.Name || got.Type != w.Type || got.JSON != w.JSON {
+			t.Errorf("field %d = %+v, want %+v", i, got, w)
+		}
+		if w.Tag != "" && got.Tag != w.Tag {
+			t.Errorf("field %s tag = %s, want %s", w.Name, got.Tag, w.Tag)
+		}
+	}
+}
+
+func TestModelStructFieldsBunRelations(t *testing.T) {
+	mg := NewModelGenerator(&ModelConfig{Name: "post", ORM: OrmBun, Fields: relationFields()})
+	tags := map[string]string{}
+	for _, f := range mg.StructFields() {
+		tags[f.Name] = f.Tag
+	}
+
+	want := map[string]string{
+		"author":   `bun:"rel:belongs-to,join:author_id=id,on_delete:CASCADE"`,
+		"profile":  `bun:"rel:has-one,join:id=post_id"`,
+		"comments": `bun:"rel:has-many,join:id=post_id"`,
+		"tags":     `bun:"m2m:post_tags,join:Post=Tag"`,
+	}
+	for name, tag := range want {
+		if tags[name] != tag {
+			t.Errorf("%s tag = %s, want %s", name, tags[name], tag)
+		}
+	}
+}
+
+func TestModelColumnTags(t *testing.T) {
+	fields := []*ModelField{
+		{Name: "id", Type: "uint64", Required: true, Primary: true},
+		{Name: "email", Type: "string", Required: true, Unique: true},
+		{Name: "nickname", Type: "string"},
+		{Name: "handle", Type: "string", Length: 191},
+	}
+
+	tests := []struct {
+		orm  OrmChoice
+		want []string
+	}{
+		{OrmGORM, []string{`gorm:"primaryKey"`, `gorm:"uniqueIndex;not null"`, "", `gorm:"size:191"`}},
+		{OrmBun, []string{`bun:",pk,autoincrement"`, `bun:",unique,notnull"`, "", `bun:",type:varchar(191)"`}},
+	}
+
+	for _, tt := range tests {
+		mg := NewModelGenerator(&ModelConfig{Name: "user", ORM: tt.orm, Fields: fields})
+		for i, f := range mg.StructFields() {
+			if f.Tag != tt.want[i] {
+				t.Errorf("%s: %s tag = %q, want %q", tt.orm, f.Name, f.Tag, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestModelBaseModelAndImports(t *testing.T) {
+	fields := []*ModelField{{Name: "published_at", Type: "time.Time"}}
+
+	bun := NewModelGenerator(&ModelConfig{Name: "blog_post", ORM: OrmBun, Fields: fields})
+	if got := bun.baseModel(); got != "bun.BaseModel `bun:\"table:blog_posts\"`" {
+		t.Errorf("unexpected bun base model %s", got)
+	}
+	if got := bun.imports(bun.StructFields()); !slices.Equal(got, []string{"time", "github.com/uptrace/bun"}) {
+		t.Errorf("unexpected bun imports %v", got)
+	}
+
+	gorm := NewModelGenerator(&ModelConfig{Name: "blog_post", ORM: OrmGORM})
+	if got := gorm.baseModel(); got != "" {
+		t.Errorf("expected no base model for gorm, got %s", got)
+	}
+	if got := gorm.imports(gorm.StructFields()); len(got) != 0 {
+		t.Errorf("expected no imports, got %v", got)
+	}
+}
+
+func TestModelGeneratePivotFromBothSides(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+	writeOptions = WriteOptions{}
+
+	sides := []*ModelConfig{
+		{Name: "tag", ORM: OrmBun, Fields: []*ModelField{{Name: "posts", Type: "relation", Relation: RelationManyToMany}}},
+		{Name: "post", ORM: OrmBun, Fields: []*ModelField{{Name: "tags", Type: "relation", Relation: RelationManyToMany}}},
+	}
+	for _, mc := range sides {
+		if err := NewModelGenerator(mc).Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrations, err := filepath.Glob(filepath.Join("internal", "migrations", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 1 || !strings.HasSuffix(migrations[0], "_create_post_tags_table.go") {
+		t.Errorf("expected a single post_tags migration, got %v", migrations)
+	}
+
+	models, err := filepath.Glob(filepath.Join("internal", "models", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join("internal", "models", "post.go"),
+		filepath.Join("internal", "models", "post_tag.go"),
+		filepath.Join("internal", "models", "tag.go"),
+	}
+	if !slices.Equal(models, want) {
+		t.Errorf("expected models %v, got %v", want, models)
+	}
+
+	for _, path := range []string{want[0], want[2]} {
+		if model := readGoFile(t, path); !strings.Contains(model, "m2m:post_tags,") {
+			t.Errorf("expected %s to join through post_tags, got:\n%s", path, model)
+		}
+	}
+}
+
+func TestModelGenerateSelfReferentialPivot(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+	writeOptions = WriteOptions{}
+
+	friends := []*ModelField{{Name: "friends", Type: "relation", Relation: RelationManyToMany, RelatedModel: "user"}}
+	if err := NewModelGenerator(&ModelConfig{Name: "user", ORM: OrmBun, Fields: friends}).Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := filepath.Glob(filepath.Join("internal", "migrations", "*_create_user_users_table.go"))
+	if err != nil || len(migrations) != 1 {
+		t.Fatalf("expected a user_users migration, got %v (%v)", migrations, err)
+	}
+	migration := readGoFile(t, migrations[0])
+	for _, want := range []string{`t.ForeignID("user_id").On("users")`, `t.ForeignID("related_user_id").On("users")`} {
+		if !strings.Contains(migration, want) {
+			t.Errorf("expected the pivot migration to contain %s, got:\n%s", want, migration)
+		}
+	}
+
+	pivot := readGoFile(t, filepath.Join("internal", "models", "user_user.go"))
+	for _, want := range []string{
+		"UserID        uint64 `json:\"user_id\" bun:\",pk\"`",
+		"RelatedUserID uint64 `json:\"related_user_id\" bun:\",pk\"`",
+		`bun:"rel:belongs-to,join:related_user_id=id"`,
+	} {
+		if !strings.Contains(pivot, want) {
+			t.Errorf("expected the pivot model to contain %s, got:\n%s", want, pivot)
+		}
+	}
+	if user := readGoFile(t, filepath.Join("internal", "models", "user.go")); !strings.Contains(user, `bun:"m2m:user_users,join:User=RelatedUser"`) {
+		t.Errorf("expected the bun relation to join User=RelatedUser, got:\n%s", user)
+	}
+
+	gorm := NewModelGenerator(&ModelConfig{Name: "user", ORM: OrmGORM, Fields: friends})
+	if tag := gorm.relationTag(friends[0]); tag != `gorm:"many2many:user_users;joinForeignKey:UserID;joinReferences:RelatedUserID"` {
+		t.Errorf("unexpected gorm tag %s", tag)
+	}
+}
+
+func TestModelCommonFieldsSoftDelete(t *testing.T) {
+	tests := []struct {
+		orm          OrmChoice
+		typ          string
+		tag          string
+		timestampTag string
+		imports      []string
+	}{
+		{OrmGORM, "gorm.DeletedAt", `gorm:"index"`, "", []string{"time", "gorm.io/gorm"}},
+		{OrmBun, "time.Time", `bun:",soft_delete,nullzero"`, `bun:",nullzero,default:current_timestamp"`, []string{"time", "github.com/uptrace/bun"}},
+	}
+
+	for _, tt := range tests {
+		mg := NewModelGenerator(&ModelConfig{Name: "post", ORM: tt.orm, Fields: CommonModelFields})
+		fields := mg.StructFields()
+		for _, f := range fields {
+			switch f.Name {
+			case "created_at", "updated_at":
+				if f.Tag != tt.timestampTag {
+					t.Errorf("%s: %s tag = %s, want %s", tt.orm, f.Name, f.Tag, tt.timestampTag)
+				}
+			case "deleted_at":
+				if f.Type != tt.typ || f.Tag != tt.tag {
+					t.Errorf("%s: deleted_at = %s %s, want %s %s", tt.orm, f.Type, f.Tag, tt.typ, tt.tag)
+				}
+			}
+		}
+		if got := mg.imports(fields); !slices.Equal(got, tt.imports) {
+			t.Errorf("%s: imports = %v, want %v", tt.orm, got, tt.imports)
+		}
+	}
+}
@@ -1,17 +1,68 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
 
 var enableExperimental bool
 
+var projectConfigFile string
+var projectFlags ProjectOptions
+
+func init() {
+	newCmd.Flags().StringVar(&projectConfigFile, "config", "", "Read project options from a YAML file")
+	newCmd.Flags().StringVar(&projectFlags.ModuleName, "module", "", "Go module name (e.g. github.com/username/repo)")
+	newCmd.Flags().StringVar(&projectFlags.Preset, "preset", "", "Project preset (mvc, rest_api)")
+	newCmd.Flags().StringVar(&projectFlags.ORM, "orm", "", "SQL ORM (gorm, bun)")
+	newCmd.Flags().StringVar(&projectFlags.Frontend, "frontend", "", "Frontend preset for mvc projects (go_templates, templ, inertia_react, inertia_vue, templ_inertia_react, templ_inertia_vue)")
+	newCmd.Flags().Bool("redis", false, "Enable Redis")
+	newCmd.Flags().Bool("auth", false, "Enable auth")
+	newCmd.Flags().Bool("gpa", false, "Enable GPA (requires --exp)")
+	newCmd.Flags().BoolVar(&scaffoldOffline, "offline", false, "Use only cached or built-in scaffolds, without network access (or set LEMMEGO_OFFLINE=1)")
+	newCmd.Flags().StringVar(&projectFlags.ScaffoldVersion, "scaffold-version", "", "Scaffold the project from a tagged scaffold version (e.g. v0.2.0), verified against its checksums")
+	newCmd.Flags().StringToStringVar(&projectFlags.Options, "option", nil, "Set an option declared in the scaffold manifest (name=value, repeatable)")
+	newCmd.Flags().StringVar(&projectFlags.Template, "template", "", "Scaffold the project from a directory or a git+<url>[#ref] repository instead of the built-in scaffold")
+}
+
+// projectOptionsFromFlags merges the config file, if any, with the flags
+// explicitly passed to the new command. Flags win over the config file.
+func projectOptionsFromFlags(cmd *cobra.Command) (ProjectOptions, error) {
+	var opts ProjectOptions
+	if projectConfigFile != "" {
+		fileOpts, err := loadProjectOptions(projectConfigFile)
+		if err != nil {
+			return opts, err
+		}
+		opts = fileOpts
+	}
+
+	flagOpts := projectFlags
+	for name, target := range map[string]**bool{
+		"redis": &flagOpts.EnableRedis,
+		"auth":  &flagOpts.EnableAuth,
+		"gpa":   &flagOpts.EnableGPA,
+	} {
+		if !cmd.Flags().Changed(name) {
+			continue
+		}
+		value, err := cmd.Flags().GetBool(name)
+		if err != nil {
+			return opts, err
+		}
+		*target = &value
+	}
+
+	return opts.Merge(flagOpts), nil
+}
+
 var newCmd = &cobra.Command{
 	Use:     "new [dirname]",
 	Aliases: []string{"n"},
@@ -22,16 +73,59 @@ var newCmd = &cobra.Command{
 		dirname := args[0]
 		dirPath := DirPath(dirname)
 
-		cfg := collectProjectConfig(dirname, enableExperimental)
-		if cfg == nil {
+		opts, err := projectOptionsFromFlags(cmd)
+		if err != nil {
+			log.Fatal("Error reading project options: ", err)
+		}
+
+		// Resolve the scaffold first so that a bad template or version fails
+		// before any prompt, and so that the prompts come from its manifest.
+		var src scaffoldSource
+		var origin ScaffoldOrigin
+		cleanupTemplate := func() {}
+		switch {
+		case opts.Template != "" && opts.ScaffoldVersion != "":
+			log.Fatal("Error: --template and --scaffold-version cannot be used together")
+		case opts.Template != "":
+			src, cleanupTemplate, err = openScaffoldTemplate(opts.Template)
+			if err != nil {
+				log.Fatal("Error: ", err)
+			}
+			origin = ScaffoldOrigin{Source: "template", Path: opts.Template, Version: scaffoldVersion(src)}
+		default:
+			src, origin, err = resolveScaffold(opts.ScaffoldVersion)
+			if err != nil {
+				log.Fatal("Error: ", err)
+			}
+		}
+
+		manifest, err := loadScaffoldManifest(src)
+		if err != nil {
+			cleanupTemplate()
+			log.Fatal("Error: ", err)
+		}
+
+		cfg, err := collectProjectConfig(dirname, manifest, opts, enableExperimental)
+		if errors.Is(err, huh.ErrUserAborted) {
+			cleanupTemplate()
 			return
 		}
+		if err != nil {
+			cleanupTemplate()
+			log.Fatal("Error: ", err)
+		}
 
 		EnsureEmptyDir(dirname)
 
-		if err := ScaffoldProject(*cfg, dirPath); err != nil {
+		fmt.Printf("> Using the %s\n", origin)
+		err = scaffoldProjectFrom(src, origin, *cfg, dirPath)
+		cleanupTemplate()
+		if err != nil {
 			log.Fatal("Error scaffolding project:", err)
 		}
+		if err := recordScaffoldOrigin(origin); err != nil {
+			fmt.Println("Warning: could not record the scaffold origin:", err)
+		}
 
 		renameModule(cfg.ModuleName, dirPath)
 		copyEnvFile(dirPath)
@@ -109,7 +203,15 @@ func generateAppKey(dirPath string) {
 
 func renameModule(newModuleName string, dirPath string) {
 	fmt.Println("> Applying module name...")
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+	if err := replaceModuleName(newModuleName, dirPath); err != nil {
+		log.Fatal("Error replacing module name:", err)
+	}
+}
+
+// replaceModuleName replaces the module path of the scaffold with
+// newModuleName in the Go, templ and go.mod files under dirPath.
+func replaceModuleName(newModuleName string, dirPath string) error {
+	return filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -125,9 +227,6 @@ func renameModule(newModuleName string, dirPath string) {
 		}
 		return nil
 	})
-	if err != nil {
-		log.Fatal("Error replacing module name:", err)
-	}
 }
 
 func npmBinary() string {
@@ -1,9 +1,19 @@
 package cli
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/huh"
+	"github.com/mattn/go-isatty"
+	"gopkg.in/yaml.v3"
 )
 
 type ProjectPreset string
@@ -44,6 +54,22 @@ func (f FrontendPreset) HasNodeDeps() bool {
 	return f.HasInertia()
 }
 
+// FormFlavor returns the flavor of the forms generated for the frontend, or
+// an empty string when no flavor fits it.
+func (f FrontendPreset) FormFlavor() string {
+	switch f {
+	case FrontendInertiaReact, FrontendTemplInertiaReact:
+		return "react"
+	case FrontendInertiaVue, FrontendTemplInertiaVue:
+		return "vue"
+	case FrontendTempl:
+		return "templ"
+	case FrontendGoTemplates:
+		return "gohtml"
+	}
+	return ""
+}
+
 type ProjectConfig struct {
 	Name        string
 	ModuleName  string
@@ -53,105 +79,304 @@ type ProjectConfig struct {
 	EnableAuth  bool
 	EnableGPA   bool
 	Frontend    FrontendPreset
+	Options     map[string]string // Values of the options declared only in the scaffold manifest
+}
+
+// newProjectConfig builds a config from the values of the manifest options.
+func newProjectConfig(name string, values map[string]string) ProjectConfig {
+	return ProjectConfig{
+		Name:        name,
+		ModuleName:  values["module"],
+		Preset:      ProjectPreset(values["preset"]),
+		ORM:         OrmChoice(values["orm"]),
+		EnableRedis: values["redis"] == "true",
+		EnableAuth:  values["auth"] == "true",
+		EnableGPA:   values["gpa"] == "true",
+		Frontend:    FrontendPreset(values["frontend"]),
+		Options:     values,
+	}
+}
+
+// values returns the value of every manifest option, keyed by option name.
+func (cfg ProjectConfig) values() map[string]string {
+	values := maps.Clone(cfg.Options)
+	if values == nil {
+		values = map[string]string{}
+	}
+	values["module"] = cfg.ModuleName
+	values["preset"] = string(cfg.Preset)
+	values["orm"] = string(cfg.ORM)
+	values["redis"] = strconv.FormatBool(cfg.EnableRedis)
+	values["auth"] = strconv.FormatBool(cfg.EnableAuth)
+	values["gpa"] = strconv.FormatBool(cfg.EnableGPA)
+	values["frontend"] = string(cfg.Frontend)
+	return values
+}
+
+// Option returns the value of a manifest option, so that stubs can use
+// options that have no field of their own: {{.Option "queue"}}.
+func (cfg ProjectConfig) Option(name string) string {
+	return cfg.values()[name]
+}
+
+// Validate reports invalid or conflicting values in a fully collected config.
+func (cfg ProjectConfig) Validate(m *ScaffoldManifest) error {
+	return m.Check(cfg.values())
+}
+
+// ProjectOptions holds the project settings supplied up front, either through
+// flags on the new command or through a config file. Empty strings and nil
+// pointers mark values that still have to be asked for.
+type ProjectOptions struct {
+	ModuleName  string `yaml:"module"`
+	Preset      string `yaml:"preset"`
+	ORM         string `yaml:"orm"`
+	EnableRedis *bool  `yaml:"redis"`
+	EnableAuth  *bool  `yaml:"auth"`
+	EnableGPA   *bool  `yaml:"gpa"`
+	Frontend    string `yaml:"frontend"`
+	Template    string `yaml:"template"` // Directory or git+ URL of a custom scaffold
+
+	ScaffoldVersion string `yaml:"scaffold_version"` // Tag of the built-in scaffold to pin
+
+	Options map[string]string `yaml:"options"` // Values of the options declared only in the scaffold manifest
 }
 
-func collectProjectConfig(dirname string, enableExperimental bool) *ProjectConfig {
-	cfg := ProjectConfig{Name: dirname}
+// loadProjectOptions reads project options from a YAML config file.
+func loadProjectOptions(path string) (ProjectOptions, error) {
+	var opts ProjectOptions
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return opts, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		return opts, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return opts, nil
+}
 
-	var moduleName string
-	var preset string
-	var orm string
-	var enableRedis string
-	var enableAuth string
-	var enableGPA string
+// Merge returns a copy of o where every value set in override takes precedence.
+func (o ProjectOptions) Merge(override ProjectOptions) ProjectOptions {
+	if override.ModuleName != "" {
+		o.ModuleName = override.ModuleName
+	}
+	if override.Preset != "" {
+		o.Preset = override.Preset
+	}
+	if override.ORM != "" {
+		o.ORM = override.ORM
+	}
+	if override.EnableRedis != nil {
+		o.EnableRedis = override.EnableRedis
+	}
+	if override.EnableAuth != nil {
+		o.EnableAuth = override.EnableAuth
+	}
+	if override.EnableGPA != nil {
+		o.EnableGPA = override.EnableGPA
+	}
+	if override.Frontend != "" {
+		o.Frontend = override.Frontend
+	}
+	if override.Template != "" {
+		o.Template = override.Template
+	}
+	if override.ScaffoldVersion != "" {
+		o.ScaffoldVersion = override.ScaffoldVersion
+	}
+	if len(override.Options) > 0 {
+		options := maps.Clone(o.Options)
+		if options == nil {
+			options = map[string]string{}
+		}
+		maps.Copy(options, override.Options)
+		o.Options = options
+	}
+	return o
+}
+
+// values returns the supplied values keyed by manifest option name.
+func (o ProjectOptions) values() map[string]string {
+	values := maps.Clone(o.Options)
+	if values == nil {
+		values = map[string]string{}
+	}
+	for name, value := range map[string]string{
+		"module":   o.ModuleName,
+		"preset":   o.Preset,
+		"orm":      o.ORM,
+		"frontend": o.Frontend,
+	} {
+		if value != "" {
+			values[name] = value
+		}
+	}
+	for name, value := range map[string]*bool{
+		"redis": o.EnableRedis,
+		"auth":  o.EnableAuth,
+		"gpa":   o.EnableGPA,
+	} {
+		if value != nil {
+			values[name] = strconv.FormatBool(*value)
+		}
+	}
+	return values
+}
 
-	formFields := []huh.Field{
-		huh.NewInput().
-			Title("Module Name (e.g. github.com/username/repo)").
-			Value(&moduleName).
+// Validate checks the values that were supplied, leaving missing ones alone.
+func (o ProjectOptions) Validate(m *ScaffoldManifest, enableExperimental bool) error {
+	return m.CheckPartial(o.values(), enableExperimental)
+}
+
+// Missing returns the names of the options that have not been supplied.
+func (o ProjectOptions) Missing(m *ScaffoldManifest, enableExperimental bool) []string {
+	return m.Missing(o.values(), enableExperimental)
+}
+
+// projectOptionFlags are the options that have a flag of their own on the
+// new command.
+var projectOptionFlags = []string{"module", "preset", "orm", "frontend", "redis", "auth", "gpa"}
+
+// optionFlag returns the flag that supplies the named option.
+func optionFlag(name string) string {
+	if slices.Contains(projectOptionFlags, name) {
+		return "--" + name
+	}
+	return "--option " + name + "=<value>"
+}
+
+func invalidChoiceError(name, value string, allowed []string) error {
+	return fmt.Errorf("invalid %s %q (expected one of: %s)", name, value, strings.Join(allowed, ", "))
+}
+
+func yesNoSelect(title string, value *string) *huh.Select[string] {
+	return huh.NewSelect[string]().
+		Title(title).
+		Options(
+			huh.NewOption("Yes", "true"),
+			huh.NewOption("No", "false"),
+		).
+		Value(value)
+}
+
+// optionField returns the prompt for opt, writing the answer to value.
+func optionField(opt *ManifestOption, value *string) huh.Field {
+	switch opt.Type {
+	case OptionConfirm:
+		return yesNoSelect(opt.Title, value)
+	case OptionInput:
+		return huh.NewInput().
+			Title(opt.Title).
+			Value(value).
 			Validate(func(s string) error {
 				if s == "" {
-					return fmt.Errorf("module name is required")
+					return fmt.Errorf("%s is required", opt.Name)
 				}
 				return nil
-			}),
-		huh.NewSelect[string]().
-			Title("Preset").
-			Options(
-				huh.NewOption("MVC", "mvc"),
-				huh.NewOption("REST API", "rest_api"),
-			).
-			Value(&preset),
-		huh.NewSelect[string]().
-			Title("Choose an SQL ORM").
-			Options(
-				huh.NewOption("GORM", "gorm"),
-				huh.NewOption("Bun", "bun"),
-			).
-			Value(&orm),
-		huh.NewSelect[string]().
-			Title("Enable Redis?").
-			Options(
-				huh.NewOption("Yes", "true"),
-				huh.NewOption("No", "false"),
-			).
-			Value(&enableRedis),
-		huh.NewSelect[string]().
-			Title("Enable Auth?").
-			Options(
-				huh.NewOption("Yes", "true"),
-				huh.NewOption("No", "false"),
-			).
-			Value(&enableAuth),
-	}
-
-	if enableExperimental {
-		formFields = append(formFields, huh.NewSelect[string]().
-			Title("Enable GPA? (experimental)").
-			Options(
-				huh.NewOption("Yes", "true"),
-				huh.NewOption("No", "false"),
-			).
-			Value(&enableGPA))
-	}
-
-	form1 := huh.NewForm(
-		huh.NewGroup(formFields...),
-	)
-
-	if err := form1.Run(); err != nil {
-		return nil
-	}
-
-	cfg.ModuleName = moduleName
-	cfg.Preset = ProjectPreset(preset)
-	cfg.ORM = OrmChoice(orm)
-	cfg.EnableRedis = enableRedis == "true"
-	cfg.EnableAuth = enableAuth == "true"
-	cfg.EnableGPA = enableGPA == "true"
-
-	if cfg.Preset == PresetMVC {
-		var frontend string
-		form2 := huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("Choose a frontend preset").
-					Options(
-						huh.NewOption("Go Templates", "go_templates"),
-						huh.NewOption("Templ (Go Templates included)", "templ"),
-						huh.NewOption("Inertia (React)", "inertia_react"),
-						huh.NewOption("Inertia (Vue)", "inertia_vue"),
-						huh.NewOption("Templ + Inertia (React)", "templ_inertia_react"),
-						huh.NewOption("Templ + Inertia (Vue)", "templ_inertia_vue"),
-					).
-					Value(&frontend),
-			),
-		)
-		if err := form2.Run(); err != nil {
-			return nil
+			})
+	}
+
+	var options []huh.Option[string]
+	for _, choice := range opt.Choices {
+		label := choice.Label
+		if label == "" {
+			label = choice.Value
+		}
+		options = append(options, huh.NewOption(label, choice.Value))
+	}
+	return huh.NewSelect[string]().
+		Title(opt.Title).
+		Options(options...).
+		Value(value)
+}
+
+// collectProjectConfig completes opts into a ProjectConfig, prompting only for
+// the values that were not supplied through flags or a config file. The
+// prompts are built from the options of the scaffold manifest.
+func collectProjectConfig(dirname string, m *ScaffoldManifest, opts ProjectOptions, enableExperimental bool) (*ProjectConfig, error) {
+	if err := opts.Validate(m, enableExperimental); err != nil {
+		return nil, err
+	}
+
+	supplied := opts.values()
+	interactive := isInteractiveTerminal()
+	if !interactive {
+		if missing := m.Missing(supplied, enableExperimental); len(missing) > 0 {
+			var flags []string
+			for _, name := range missing {
+				flags = append(flags, optionFlag(name))
+			}
+			return nil, fmt.Errorf("missing required options: %s", strings.Join(flags, ", "))
+		}
+	}
+
+	answers := map[string]*string{}
+	current := func() map[string]string {
+		values := maps.Clone(supplied)
+		for name, answer := range answers {
+			values[name] = *answer
+		}
+		return values
+	}
+
+	// Options without a condition share a page. An option with a condition
+	// gets a page of its own, hidden while the condition is not met, unless
+	// the values no answer can change already rule it out: those supplied,
+	// the defaults of options that aren't asked and the options ruled out.
+	var groups []*huh.Group
+	var fields []huh.Field
+	flush := func() {
+		if len(fields) > 0 {
+			groups = append(groups, huh.NewGroup(fields...))
+			fields = nil
 		}
-		cfg.Frontend = FrontendPreset(frontend)
 	}
+	decided := maps.Clone(supplied)
+	for _, opt := range m.Options {
+		if opt.When.excludedBy(decided) {
+			decided[opt.Name] = ""
+			continue
+		}
+		if supplied[opt.Name] != "" {
+			continue
+		}
+		// Without a terminal, optional options take their default in Complete.
+		if !opt.asked(enableExperimental) || opt.Optional && !interactive {
+			decided[opt.Name] = opt.Default
+			continue
+		}
+		answer := opt.Default
+		answers[opt.Name] = &answer
+		if len(opt.When) == 0 {
+			fields = append(fields, optionField(opt, &answer))
+			continue
+		}
+		flush()
+		when := opt.When
+		groups = append(groups, huh.NewGroup(optionField(opt, &answer)).
+			WithHideFunc(func() bool { return !when.Matches(current()) }))
+	}
+	flush()
+
+	if len(groups) > 0 {
+		if err := huh.NewForm(groups...).Run(); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := newProjectConfig(dirname, m.Complete(current(), enableExperimental))
+	if err := cfg.Validate(m); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
 
-	return &cfg
+// isInteractiveTerminal reports whether stdin is attached to a terminal, so
+// that prompts can be answered. Character devices such as /dev/null aren't.
+func isInteractiveTerminal() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
 }
@@ -1,6 +1,11 @@
 package cli
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestFrontendPresetHasInertia(t *testing.T) {
 	tests := []struct {
@@ -61,3 +66,137 @@ func TestFrontendPresetHasNodeDeps(t *testing.T) {
 		}
 	}
 }
+
+func TestProjectConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ProjectConfig
+		wantErr bool
+	}{
+		{"mvc", ProjectConfig{ModuleName: "m", Preset: PresetMVC, ORM: OrmGORM, Frontend: FrontendTempl}, false},
+		{"rest_api", ProjectConfig{ModuleName: "m", Preset: PresetRESTAPI, ORM: OrmBun}, false},
+		{"missing module", ProjectConfig{Preset: PresetRESTAPI, ORM: OrmBun}, true},
+		{"unknown preset", ProjectConfig{ModuleName: "m", Preset: "spa", ORM: OrmGORM}, true},
+		{"unknown orm", ProjectConfig{ModuleName: "m", Preset: PresetRESTAPI, ORM: "ent"}, true},
+		{"mvc without frontend", ProjectConfig{ModuleName: "m", Preset: PresetMVC, ORM: OrmGORM}, true},
+		{"rest_api with frontend", ProjectConfig{ModuleName: "m", Preset: PresetRESTAPI, ORM: OrmGORM, Frontend: FrontendTempl}, true},
+	}
+	for _, tt := range tests {
+		err := tt.cfg.Validate(testManifest(t))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProjectOptionsValidate(t *testing.T) {
+	yes := true
+	tests := []struct {
+		name    string
+		opts    ProjectOptions
+		exp     bool
+		wantErr bool
+	}{
+		{"empty", ProjectOptions{}, false, false},
+		{"frontend only", ProjectOptions{Frontend: "inertia_vue"}, false, false},
+		{"frontend with rest_api", ProjectOptions{Preset: "rest_api", Frontend: "templ"}, false, true},
+		{"unknown frontend", ProjectOptions{Frontend: "svelte"}, false, true},
+		{"gpa without exp", ProjectOptions{EnableGPA: &yes}, false, true},
+		{"gpa with exp", ProjectOptions{EnableGPA: &yes}, true, false},
+	}
+	for _, tt := range tests {
+		err := tt.opts.Validate(testManifest(t), tt.exp)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProjectOptionsMergeAndMissing(t *testing.T) {
+	no := false
+	file := ProjectOptions{ModuleName: "github.com/acme/app", Preset: "mvc", ORM: "gorm", EnableRedis: &no}
+	flags := ProjectOptions{Preset: "rest_api", EnableAuth: &no}
+
+	opts := file.Merge(flags)
+	if opts.Preset != "rest_api" {
+		t.Errorf("expected flag preset to win, got %s", opts.Preset)
+	}
+	if opts.ModuleName != "github.com/acme/app" {
+		t.Errorf("expected module from file, got %s", opts.ModuleName)
+	}
+
+	if missing := opts.Missing(testManifest(t), false); len(missing) != 0 {
+		t.Errorf("expected nothing missing, got %v", missing)
+	}
+	if missing := opts.Missing(testManifest(t), true); len(missing) != 1 || missing[0] != "gpa" {
+		t.Errorf("expected only gpa missing with --exp, got %v", missing)
+	}
+}
+
+func TestLoadProjectOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "project.yaml")
+	data := "module: github.com/acme/app\npreset: mvc\norm: bun\nredis: true\nauth: false\nfrontend: inertia_react\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := loadProjectOptions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.ORM != "bun" || opts.Frontend != "inertia_react" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts.EnableRedis == nil || !*opts.EnableRedis {
+		t.Error("expected redis to be enabled")
+	}
+	if opts.EnableAuth == nil || *opts.EnableAuth {
+		t.Error("expected auth to be explicitly disabled")
+	}
+
+	if err := os.WriteFile(path, []byte("modul: typo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadProjectOptions(path); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestCollectProjectConfigWithoutTerminal(t *testing.T) {
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	defer func(f *os.File) { os.Stdin = f }(os.Stdin)
+	os.Stdin = stdin
+
+	no := false
+	opts := ProjectOptions{ModuleName: "example.com/api1", Preset: "rest_api", ORM: "gorm", EnableRedis: &no, EnableAuth: &no}
+	cfg, err := collectProjectConfig("api1", testManifest(t), opts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Preset != PresetRESTAPI || cfg.Frontend != "" || cfg.ORM != OrmGORM {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if cfg.Option("queue") != "true" {
+		t.Errorf("expected the optional queue option to take its default, got %q", cfg.Option("queue"))
+	}
+}
+
+func TestCollectProjectConfigMissingWithoutTerminal(t *testing.T) {
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	defer func(f *os.File) { os.Stdin = f }(os.Stdin)
+	os.Stdin = stdin
+
+	opts := ProjectOptions{ModuleName: "example.com/api1", Preset: "rest_api"}
+	_, err = collectProjectConfig("api1", testManifest(t), opts, false)
+	if err == nil || !strings.Contains(err.Error(), "missing required options: --orm, --redis, --auth") {
+		t.Errorf("expected the missing options to be reported instead of prompted for, got %v", err)
+	}
+}
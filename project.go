@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The detect helpers below take the project's lemmego.json, as read once by
+// the command with currentProjectManifest, and guess from the files of the
+// project in the current directory when it is nil.
+
+// detectProjectPreset returns the preset of the project from lemmego.json, or
+// guesses it: only MVC projects have a web routes file.
+func detectProjectPreset(pm *ProjectManifest) ProjectPreset {
+	if pm != nil {
+		return pm.Preset
+	}
+	if fileExists(filepath.Join("internal", "routes", "web.go")) {
+		return PresetMVC
+	}
+	return PresetRESTAPI
+}
+
+// detectFormFlavor returns the form flavor that fits the frontend recorded in
+// lemmego.json, or guesses it from the files of the project. It returns an
+// empty string when no form should be generated.
+func detectFormFlavor(pm *ProjectManifest) string {
+	if pm != nil {
+		if pm.Preset != PresetMVC {
+			return ""
+		}
+		return pm.Frontend.FormFlavor()
+	}
+	if detectProjectPreset(nil) != PresetMVC {
+		return ""
+	}
+	if fileExists(filepath.Join("resources", "js", "app.tsx")) {
+		return "react"
+	}
+	if matches, _ := filepath.Glob(filepath.Join("resources", "js", "Pages", "*.vue")); len(matches) > 0 {
+		return "vue"
+	}
+	if matches, _ := filepath.Glob(filepath.Join("templates", "*.templ")); len(matches) > 0 {
+		return "templ"
+	}
+	if matches, _ := filepath.Glob(filepath.Join("templates", "*.page.gohtml")); len(matches) > 0 {
+		return "gohtml"
+	}
+	return ""
+}
+
+// detectViewFlavor returns the flavor of the views generated handlers
+// render: none in REST API projects, which respond with JSON, and the templ,
+// react, vue or gohtml frontend in MVC projects.
+func detectViewFlavor(pm *ProjectManifest) string {
+	if detectProjectPreset(pm) != PresetMVC {
+		return ""
+	}
+	if flavor := detectFormFlavor(pm); flavor != "" {
+		return flavor
+	}
+	return "gohtml"
+}
+
+// detectProjectORM reports which ORM the project uses, as recorded in
+// lemmego.json or based on the connector it depends on in go.mod or registers
+// in bootstrap/providers.go. It falls back to GORM, the default of lemmego new.
+func detectProjectORM(pm *ProjectManifest) OrmChoice {
+	if pm != nil && pm.ORM != "" {
+		return pm.ORM
+	}
+	for _, file := range []string{"go.mod", filepath.Join("bootstrap", "providers.go")} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		content := string(data)
+		if strings.Contains(content, "github.com/lemmego/bunconnector") {
+			return OrmBun
+		}
+		if strings.Contains(content, "github.com/lemmego/gormconnector") {
+			return OrmGORM
+		}
+	}
+	return OrmGORM
+}
+
+// projectUsesTempl reports whether the project has templ files to generate,
+// as recorded in lemmego.json or else guessed from its files.
+func projectUsesTempl(pm *ProjectManifest) bool {
+	if pm != nil {
+		return hasTemplGenerate(pm.Config())
+	}
+	return hasTemplFiles()
+}
+
+// projectHasNodeDeps reports whether the project has frontend assets built
+// with Node, as recorded in lemmego.json or else guessed from its files.
+func projectHasNodeDeps(pm *ProjectManifest) bool {
+	if pm != nil {
+		return hasNodeDeps(pm.Config())
+	}
+	return fileExists("package.json")
+}
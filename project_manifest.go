@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// projectManifestFile is written at the root of every new project.
+const projectManifestFile = "lemmego.json"
+
+// ProjectManifest records how a project was created, so that later commands
+// and generators read the choices instead of guessing them from the files.
+type ProjectManifest struct {
+	Preset     ProjectPreset     `json:"preset"`
+	ORM        OrmChoice         `json:"orm"`
+	Frontend   FrontendPreset    `json:"frontend,omitempty"`
+	Redis      bool              `json:"redis"`
+	Auth       bool              `json:"auth"`
+	GPA        bool              `json:"gpa"`
+	Options    map[string]string `json:"options,omitempty"` // Options declared only in the scaffold manifest
+	Scaffold   ScaffoldOrigin    `json:"scaffold"`
+	CLIVersion string            `json:"cli_version"`
+}
+
+func newProjectManifest(cfg ProjectConfig, origin ScaffoldOrigin) ProjectManifest {
+	pm := ProjectManifest{
+		Preset:     cfg.Preset,
+		ORM:        cfg.ORM,
+		Frontend:   cfg.Frontend,
+		Redis:      cfg.EnableRedis,
+		Auth:       cfg.EnableAuth,
+		GPA:        cfg.EnableGPA,
+		Scaffold:   origin,
+		CLIVersion: rootCmd.Version,
+	}
+	for name, value := range cfg.Options {
+		if !slices.Contains(projectOptionFlags, name) && value != "" {
+			if pm.Options == nil {
+				pm.Options = map[string]string{}
+			}
+			pm.Options[name] = value
+		}
+	}
+	return pm
+}
+
+// Config returns the project config the manifest was written from.
+func (pm ProjectManifest) Config() ProjectConfig {
+	return ProjectConfig{
+		Preset:      pm.Preset,
+		ORM:         pm.ORM,
+		Frontend:    pm.Frontend,
+		EnableRedis: pm.Redis,
+		EnableAuth:  pm.Auth,
+		EnableGPA:   pm.GPA,
+		Options:     maps.Clone(pm.Options),
+	}
+}
+
+func writeProjectManifest(destDir string, pm ProjectManifest) error {
+	data, err := json.MarshalIndent(pm, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(destDir, projectManifestFile), append(data, '\n'), 0644)
+}
+
+// loadProjectManifest reads the manifest of the project in the current
+// directory. The error wraps fs.ErrNotExist for projects created before the
+// manifest existed.
+func loadProjectManifest() (*ProjectManifest, error) {
+	data, err := os.ReadFile(projectManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var pm ProjectManifest
+	if err := json.Unmarshal(data, &pm); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", projectManifestFile, err)
+	}
+	return &pm, nil
+}
+
+// currentProjectManifest returns the manifest of the project in the current
+// directory, or nil when there is none. A broken manifest is reported and
+// ignored, so that callers fall back to guessing.
+func currentProjectManifest() *ProjectManifest {
+	pm, err := loadProjectManifest()
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Println("Warning:", err)
+		}
+		return nil
+	}
+	return pm
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	cfg := newProjectConfig("app", map[string]string{
+		"module":   "github.com/acme/app",
+		"preset":   "mvc",
+		"orm":      "bun",
+		"frontend": "templ_inertia_react",
+		"redis":    "false",
+		"auth":     "true",
+		"gpa":      "false",
+		"queue":    "false",
+	})
+	origin := ScaffoldOrigin{Source: "cache", Ref: "v1.2.0", Version: "1.2.0"}
+	if err := writeProjectManifest(dir, newProjectManifest(cfg, origin)); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+	pm, err := loadProjectManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.Scaffold.Ref != "v1.2.0" || !pm.Auth || pm.Redis {
+		t.Errorf("unexpected manifest %+v", pm)
+	}
+	if len(pm.Options) != 1 || pm.Options["queue"] != "false" {
+		t.Errorf("expected only the manifest-only option to be recorded, got %v", pm.Options)
+	}
+
+	if got := detectProjectORM(pm); got != OrmBun {
+		t.Errorf("detectProjectORM(pm) = %s, want bun", got)
+	}
+	if got := detectFormFlavor(pm); got != "react" {
+		t.Errorf("detectFormFlavor(pm) = %q, want react", got)
+	}
+	if !projectUsesTempl(pm) || !projectHasNodeDeps(pm) {
+		t.Error("expected templ and node dependencies from the manifest")
+	}
+}
+
+func TestDetectWithoutProjectManifest(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("package.json", []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pm := currentProjectManifest()
+	if !projectHasNodeDeps(pm) {
+		t.Error("expected node dependencies to be guessed from package.json")
+	}
+	if detectProjectPreset(pm) != PresetRESTAPI {
+		t.Error("expected a project without web routes to be guessed as rest_api")
+	}
+}
+
+func TestFrontendPresetFormFlavor(t *testing.T) {
+	tests := map[FrontendPreset]string{
+		FrontendGoTemplates:       "gohtml",
+		FrontendTempl:             "templ",
+		FrontendInertiaReact:      "react",
+		FrontendTemplInertiaReact: "react",
+		FrontendInertiaVue:        "vue",
+		FrontendTemplInertiaVue:   "vue",
+	}
+	for frontend, want := range tests {
+		if got := frontend.FormFlavor(); got != want {
+			t.Errorf("%s.FormFlavor() = %q, want %q", frontend, got, want)
+		}
+	}
+}
+
+// captureStdout returns what f prints to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(f *os.File) { os.Stdout = f }(os.Stdout)
+	os.Stdout = w
+
+	done := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- out
+	}()
+	f()
+	w.Close()
+	return string(<-done)
+}
+
+func TestBuildReadsProjectManifestOnce(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll(filepath.Join("cmd", "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("cmd", "app", "main.go"), []byte("package main\n\nimport _ \""+lemmegoIndicator+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(projectManifestFile, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() { buildCmd.Run(buildCmd, nil) })
+	if strings.Count(out, "Warning:") != 1 || !strings.Contains(out, "Nothing to build") {
+		t.Errorf("expected a single warning about lemmego.json, got:\n%s", out)
+	}
+}
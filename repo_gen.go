@@ -0,0 +1,55 @@
+package cli
+
+import (
+	_ "embed"
+	"strings"
+
+	"github.com/gertd/go-pluralize"
+)
+
+type RepoConfig struct {
+	Name string // Name of the model
+	ORM  OrmChoice
+}
+
+// RepoGenerator writes the repository the CRUD handlers of a model use, in
+// the style of the repos.UserRepository of the auth overlays.
+type RepoGenerator struct {
+	name string
+	orm  OrmChoice
+}
+
+func NewRepoGenerator(rc *RepoConfig) *RepoGenerator {
+	return &RepoGenerator{rc.Name, rc.ORM}
+}
+
+func (rg *RepoGenerator) GetPackagePath() string {
+	return "internal/repos"
+}
+
+func (rg *RepoGenerator) GetStub() string {
+	return loadStub("repo.txt")
+}
+
+func (rg *RepoGenerator) Generate() error {
+	moduleName, err := GetModuleName()
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(rg.GetPackagePath(), "/")
+	tmplData := map[string]interface{}{
+		"PackageName": parts[len(parts)-1],
+		"Module":      moduleName,
+		"Model":       rg.name,
+		"Plural":      pluralize.NewClient().Plural(rg.name),
+		"ORM":         string(rg.orm),
+	}
+
+	output, err := ParseTemplate(tmplData, rg.GetStub(), CommonFuncs)
+	if err != nil {
+		return err
+	}
+
+	return writeGeneratedGoFile("repo.txt", rg.GetPackagePath()+"/"+rg.name+"_repo.go", []byte(output))
+}
@@ -0,0 +1,352 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/gertd/go-pluralize"
+	"github.com/spf13/cobra"
+)
+
+// resourceFieldTypes are the UI types a resource field can have. Each of them
+// has an entry in UiDataTypeMap and UiDbTypeMap.
+var resourceFieldTypes = []string{
+	"text", "textarea", "integer", "decimal", "boolean", "radio", "checkbox", "dropdown", "date", "time", "file",
+}
+
+type ResourceField struct {
+	Name     string
+	Type     string
+	Required bool
+	Unique   bool
+	Choices  []string
+}
+
+type ResourceConfig struct {
+	Name    string
+	Flavor  string // templ, react, vue, gohtml; empty responds with JSON, without views
+	Fields  []*ResourceField
+	Routes  RouteOptions
+	Project *ProjectManifest // lemmego.json of the project; nil guesses from its files
+}
+
+// ResourceGenerator fans a single field list out to the model, migration,
+// input, handler and form generators, then registers the handlers in the
+// project's routes file.
+type ResourceGenerator struct {
+	name    string
+	flavor  string
+	fields  []*ResourceField
+	routes  RouteOptions
+	project *ProjectManifest
+}
+
+func NewResourceGenerator(rc *ResourceConfig) *ResourceGenerator {
+	return &ResourceGenerator{rc.Name, rc.Flavor, rc.Fields, rc.Routes, rc.Project}
+}
+
+// ParseResourceFields converts command-line field specs such as
+// "title:text:required" or "role:dropdown:admin,editor" into resource fields.
+// For radio, checkbox and dropdown fields every modifier other than required
+// and unique is taken as a comma separated list of choices.
+func ParseResourceFields(specs []string) ([]*ResourceField, error) {
+	parsed, err := ParseFieldSpecs(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []*ResourceField
+	for _, spec := range parsed {
+		if !slices.Contains(resourceFieldTypes, spec.Type) {
+			return nil, fmt.Errorf("field %q: unknown type %q (expected one of: %s)", spec.Name, spec.Type, strings.Join(resourceFieldTypes, ", "))
+		}
+		if slices.ContainsFunc(CommonModelFields, func(f *ModelField) bool { return f.Name == spec.Name }) {
+			return nil, fmt.Errorf("field %q is provided by default", spec.Name)
+		}
+
+		field := &ResourceField{Name: spec.Name, Type: spec.Type}
+		for _, m := range spec.Modifiers {
+			switch m {
+			case "required":
+				field.Required = true
+			case "unique":
+				field.Unique = true
+			default:
+				if !resourceTypeHasChoices(spec.Type) {
+					return nil, fmt.Errorf("field %q: unknown modifier %q (expected required or unique)", spec.Name, m)
+				}
+				field.Choices = append(field.Choices, strings.Split(m, ",")...)
+			}
+		}
+		if resourceTypeHasChoices(spec.Type) && len(field.Choices) == 0 {
+			return nil, fmt.Errorf("field %q: %s needs choices, e.g. %s:%s:one,two", spec.Name, spec.Type, spec.Name, spec.Type)
+		}
+
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func resourceTypeHasChoices(fieldType string) bool {
+	return fieldType == "radio" || fieldType == "checkbox" || fieldType == "dropdown"
+}
+
+func (rg *ResourceGenerator) tableName() string {
+	return pluralize.NewClient().Plural(rg.name)
+}
+
+func (rg *ResourceGenerator) ModelFields() []*ModelField {
+	fields := append([]*ModelField{}, CommonModelFields...)
+	for _, f := range rg.fields {
+		fields = append(fields, &ModelField{
+			Name:     f.Name,
+			Type:     UiDataTypeMap[f.Type],
+			Required: f.Required,
+			Unique:   f.Unique,
+		})
+	}
+	return fields
+}
+
+func (rg *ResourceGenerator) MigrationFields() []*MigrationField {
+	fields := []*MigrationField{
+		{Name: "id", Type: "bigIncrements"},
+	}
+	for _, f := range rg.fields {
+		fields = append(fields, &MigrationField{
+			Name:     f.Name,
+			Type:     UiDbTypeMap[f.Type],
+			Nullable: !f.Required,
+			Unique:   f.Unique,
+		})
+	}
+	return fields
+}
+
+func (rg *ResourceGenerator) InputFields() []*InputField {
+	var fields []*InputField
+	for _, f := range rg.fields {
+		field := &InputField{
+			Name:     f.Name,
+			Type:     UiDataTypeMap[f.Type],
+			Required: f.Required,
+			Unique:   f.Unique,
+		}
+		if f.Type == "file" {
+			field.Type = "file"
+		}
+		if f.Unique {
+			field.Table = rg.tableName()
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func (rg *ResourceGenerator) FormFields() []*FormField {
+	var fields []*FormField
+	for _, f := range rg.fields {
+		fields = append(fields, &FormField{Name: f.Name, Type: f.Type, Choices: f.Choices, Required: f.Required})
+	}
+	return fields
+}
+
+// HandlerFields returns the fields the handlers copy from the input onto the
+// model. Files are left out, the input has them as uploads.
+func (rg *ResourceGenerator) HandlerFields() []*HandlerField {
+	fields := []*HandlerField{}
+	for _, f := range rg.fields {
+		if f.Type != "file" {
+			fields = append(fields, &HandlerField{Name: f.Name})
+		}
+	}
+	return fields
+}
+
+func (rg *ResourceGenerator) Generate() error {
+	if err := NewModelGenerator(&ModelConfig{Name: rg.name, ORM: detectProjectORM(rg.project), Fields: rg.ModelFields()}).Generate(); err != nil {
+		return fmt.Errorf("generating model: %w", err)
+	}
+	fmt.Println("Model generated successfully.")
+
+	mg := NewMigrationGenerator(&MigrationConfig{
+		TableName:  rg.tableName(),
+		Fields:     rg.MigrationFields(),
+		Timestamps: true,
+	})
+	if err := mg.Generate(); err != nil {
+		return fmt.Errorf("generating migration: %w", err)
+	}
+	fmt.Println("Migration generated successfully.")
+
+	if err := NewInputGenerator(&InputConfig{Name: rg.name, ORM: detectProjectORM(rg.project), Fields: rg.InputFields()}).Generate(); err != nil {
+		return fmt.Errorf("generating input: %w", err)
+	}
+	fmt.Println("Input generated successfully.")
+
+	hg := NewHandlerGenerator(&HandlerConfig{
+		Name:   rg.name,
+		Model:  rg.name,
+		Flavor: rg.flavor,
+		ORM:    detectProjectORM(rg.project),
+		Fields: rg.HandlerFields(),
+		Form:   rg.FormFields(),
+		Prefix: rg.routes.Prefix,
+	})
+	if err := hg.Generate(); err != nil {
+		return fmt.Errorf("generating handlers: %w", err)
+	}
+	fmt.Println("Handler generated successfully.")
+
+	routesFile, err := RegisterResourceRoutes(rg.name, detectProjectPreset(rg.project), rg.routes)
+	if err != nil {
+		return fmt.Errorf("registering routes: %w", err)
+	}
+	fmt.Printf("Routes registered in %s.\n", routesFile)
+
+	return nil
+}
+
+func (rg *ResourceGenerator) Command() *cobra.Command {
+	return resourceCmd
+}
+
+var resourceFlavor string
+var resourceRouteOptions RouteOptions
+
+func init() {
+	resourceCmd.Flags().StringVarP(&resourceFlavor, "flavor", "f", "", "Form flavor (templ, react, vue, gohtml); detected from the project when omitted")
+	resourceCmd.Flags().StringVar(&resourceRouteOptions.Prefix, "prefix", "", "Register the routes in a route group with this path")
+	resourceCmd.Flags().StringSliceVar(&resourceRouteOptions.Middleware, "middleware", nil, "Middleware to run before each route, e.g. auth.Protected")
+}
+
+var resourceCmd = &cobra.Command{
+	Use:     "resource [name] [field:type[:modifier]...]",
+	Aliases: []string{"scaffold"},
+	Short:   "Generate a model, migration, input, handlers, form and routes",
+	Long:    `Generate a model, migration, input, handlers, form and routes for a resource from a single field list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var resourceName string
+		var fields []*ResourceField
+
+		if !shouldRunInteractively && len(args) == 0 {
+			fmt.Println("Please provide a resource name")
+			return
+		}
+
+		if shouldRunInteractively && len(args) == 0 {
+			nameForm := huh.NewForm(
+				huh.NewGroup(
+					huh.NewInput().
+						Title("Enter the resource name in snake_case and singular form").
+						Value(&resourceName).
+						Validate(SnakeCase),
+				),
+			)
+			err := nameForm.Run()
+			if err != nil {
+				return
+			}
+
+			for {
+				var fieldName, fieldType string
+				var choices []string
+				const required = "Required"
+				const unique = "Unique"
+				selectedAttrs := []string{}
+
+				fieldForm := huh.NewForm(
+					huh.NewGroup(
+						huh.NewInput().
+							Title("Enter the field name in snake_case.\nThe following fields will be provided:\nid, created_at, updated_at, deleted_at").
+							Validate(SnakeCaseEmptyAllowed).
+							Validate(
+								NotIn(
+									[]string{"id", "created_at", "updated_at", "deleted_at"},
+									"No need, this field will be provided",
+								),
+							).
+							Value(&fieldName),
+					),
+				)
+				err := fieldForm.Run()
+				if err != nil {
+					return
+				}
+				if fieldName == "" {
+					break
+				}
+
+				fieldTypeForm := huh.NewForm(
+					huh.NewGroup(
+						huh.NewSelect[string]().
+							Title("Select the field type").
+							Options(huh.NewOptions(resourceFieldTypes...)...).
+							Value(&fieldType),
+						huh.NewMultiSelect[string]().
+							Title("Press x to select the attributes").
+							Options(huh.NewOptions(required, unique)...).
+							Value(&selectedAttrs),
+					),
+				)
+				err = fieldTypeForm.Run()
+				if err != nil {
+					return
+				}
+
+				if resourceTypeHasChoices(fieldType) {
+					for {
+						var choice string
+						err = huh.NewInput().
+							Title(fmt.Sprintf("Add new choice for %s %s (Press enter to finish)", fieldName, fieldType)).
+							Value(&choice).
+							Run()
+						if err != nil {
+							return
+						}
+						if choice == "" {
+							break
+						}
+						choices = append(choices, choice)
+					}
+				}
+
+				fields = append(fields, &ResourceField{
+					Name:     fieldName,
+					Type:     fieldType,
+					Required: slices.Contains(selectedAttrs, required),
+					Unique:   slices.Contains(selectedAttrs, unique),
+					Choices:  choices,
+				})
+			}
+		} else {
+			resourceName = args[0]
+			var err error
+			fields, err = ParseResourceFields(args[1:])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+
+		if !isLemmegoProject() {
+			fmt.Println("Error: This does not appear to be a Lemmego project directory.")
+			return
+		}
+
+		pm := currentProjectManifest()
+		flavor := resourceFlavor
+		if flavor == "" {
+			flavor = detectViewFlavor(pm)
+		}
+
+		rg := NewResourceGenerator(&ResourceConfig{Name: resourceName, Flavor: flavor, Fields: fields, Routes: resourceRouteOptions, Project: pm})
+		if err := rg.Generate(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println("Resource generated successfully.")
+	},
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"html/template"
+	"path/filepath"
+	"testing"
+)
+
+// TestResourceGenerateBuildsMVCProject generates a resource in a freshly
+// scaffolded go_templates project and builds the project's internal packages
+// with what was generated: the model, migration, input, repository, handlers
+// and routes. The views have to be there for every page the handlers render.
+func TestResourceGenerateBuildsMVCProject(t *testing.T) {
+	src, origin := embeddedScaffold()
+	cfg := ProjectConfig{Name: "app", ModuleName: "example.com/app", Preset: PresetMVC, ORM: OrmGORM, Frontend: FrontendGoTemplates}
+	dir := t.TempDir()
+	if err := renderScaffold(src, origin, cfg, dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	defer func(opts WriteOptions) { writeOptions = opts }(writeOptions)
+	writeOptions = WriteOptions{}
+	// The scaffold's go.mod requires modules that aren't needed to build
+	// internal/, so they don't have to be in the module cache.
+	offlineModule(t, generatedCodeRequires(t))
+
+	pm, err := loadProjectManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := ParseResourceFields([]string{"title:text:required:unique", "body:textarea", "status:dropdown:draft,published", "views:integer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rg := NewResourceGenerator(&ResourceConfig{Name: "post", Flavor: detectViewFlavor(pm), Fields: fields, Project: pm})
+	if err := rg.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Parsed the way lemmego/api's res package caches the pages.
+	layout := filepath.Join("templates", "base.layout.gohtml")
+	for _, view := range []string{"post_index.page.gohtml", "post_show.page.gohtml", "post.page.gohtml"} {
+		page := filepath.Join("templates", view)
+		funcs := template.FuncMap{"csrf": func() template.HTML { return "" }}
+		if _, err := template.New(view).Funcs(funcs).ParseFiles(page, layout); err != nil {
+			t.Errorf("expected the resource to come with %s: %v", view, err)
+		}
+	}
+	goOffline(t, "build", "./internal/...")
+}
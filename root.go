@@ -27,20 +27,30 @@ func AddCmd(cmd *cobra.Command) {
 func Execute() error {
 	newCmd.Flags().BoolVar(&enableExperimental, "exp", false, "Enable experimental features (GPA)")
 	genCmd.PersistentFlags().BoolVarP(&shouldRunInteractively, "interactive", "i", false, "Run interactively")
+	genCmd.PersistentFlags().BoolVar(&writeOptions.DryRun, "dry-run", false, "Print the files that would be generated without writing them")
+	genCmd.PersistentFlags().BoolVar(&writeOptions.Diff, "diff", false, "Print a unified diff against existing files without writing them")
+	genCmd.PersistentFlags().BoolVar(&writeOptions.Force, "force", false, "Overwrite files that already exist")
 
 	genCmd.AddCommand(handlerCmd)
 	genCmd.AddCommand(migrationCmd)
 	genCmd.AddCommand(modelCmd)
 	genCmd.AddCommand(inputCmd)
 	genCmd.AddCommand(formCmd)
+	genCmd.AddCommand(resourceCmd)
+	genCmd.AddCommand(fromDBCmd)
 
 	AddCmd(newCmd)
+	AddCmd(addCmd)
 	AddCmd(runCmd)
 	AddCmd(devCmd)
 	AddCmd(buildCmd)
 	AddCmd(genCmd)
 	AddCmd(inertiaSSRCmd)
+	AddCmd(cacheCmd)
 	AddCmd(cacheCleanCmd)
+	AddCmd(scaffoldCmd)
+	AddCmd(stubCmd)
+	AddCmd(routesCmd)
 
 	return rootCmd.Execute()
 }
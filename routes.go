@@ -0,0 +1,417 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/tools/go/packages"
+)
+
+// Route is a route registration found in the project's routes package.
+type Route struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware"` // run before the handler
+	After      []string `json:"after"`      // run after the handler
+	Position   string   `json:"position"`   // file:line of the registration
+}
+
+// routeMethods maps the router methods that register a route to the HTTP
+// method they register it for.
+var routeMethods = map[string]string{
+	"Get":     "GET",
+	"Post":    "POST",
+	"Put":     "PUT",
+	"Patch":   "PATCH",
+	"Delete":  "DELETE",
+	"Head":    "HEAD",
+	"Options": "OPTIONS",
+	"Connect": "CONNECT",
+	"Trace":   "TRACE",
+}
+
+// ListRoutes statically analyzes the internal/routes package of the project
+// in dir and returns its routes in the order they are registered. Nothing is
+// run, so it works without a database or a running app. Type information is
+// used to name handlers and resolve constant paths where the package
+// type-checks, and the syntax alone otherwise.
+func ListRoutes(dir string) ([]Route, error) {
+	routesDir, err := filepath.Abs(filepath.Join(dir, "internal", "routes"))
+	if err != nil {
+		return nil, err
+	}
+	// Dependencies are type-checked from source rather than export data, which
+	// ties the loader to the export format of the Go toolchain. Only their
+	// declarations matter, so function bodies outside the routes are dropped.
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedImports |
+			packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir: dir,
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			file, err := parser.ParseFile(fset, filename, src, parser.AllErrors|parser.ParseComments)
+			if filepath.Dir(filename) != routesDir && file != nil {
+				for _, decl := range file.Decls {
+					if fn, ok := decl.(*ast.FuncDecl); ok {
+						fn.Body = nil
+					}
+				}
+			}
+			return file, err
+		},
+	}
+	pkgs, err := packages.Load(cfg, "./internal/routes")
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []Route
+	for _, pkg := range pkgs {
+		if len(pkg.Syntax) == 0 {
+			if len(pkg.Errors) > 0 {
+				return nil, pkg.Errors[0]
+			}
+			continue
+		}
+		routes = append(routes, packageRoutes(pkg, dir)...)
+	}
+	return routes, nil
+}
+
+// routeGroup is a router or route group variable with the path prefix and
+// the middleware its routes are registered with.
+type routeGroup struct {
+	prefix string
+	before []string
+	after  []string
+	// router is the router a group was created from, nil for routers. A
+	// router's middleware applies to the routes its groups register after it
+	// is added, like the group's own.
+	router *routeGroup
+}
+
+// middleware returns the middleware run before and after the handlers of a
+// route registered on g now, in the order lemmego/api runs them.
+func (g *routeGroup) middleware() (before, after []string) {
+	if g.router == nil {
+		return slices.Clone(g.before), slices.Clone(g.after)
+	}
+	return slices.Concat(g.router.before, g.before), slices.Concat(g.after, g.router.after)
+}
+
+// group returns a group created from g with Group(prefix). It starts with
+// the middleware of g when g is a group itself.
+func (g *routeGroup) group(prefix string) *routeGroup {
+	if g.router == nil {
+		return &routeGroup{prefix: prefix, router: g}
+	}
+	return &routeGroup{
+		prefix: joinRoutePath(g.prefix, prefix),
+		before: slices.Clone(g.before),
+		after:  slices.Clone(g.after),
+		router: g.router,
+	}
+}
+
+// packageRoutes returns the routes registered in the functions of pkg.
+// Routers are the variables assigned a Router() or Group() result and the
+// parameters whose type is named Router or ends in Group. UseBefore and
+// UseAfter add middleware to the routes registered after them on a router or
+// group, or to a single route when chained to its registration. Use wraps
+// the whole router in net/http middleware, so it is listed first for every
+// route of the package.
+func packageRoutes(pkg *packages.Package, dir string) []Route {
+	files := slices.Clone(pkg.Syntax)
+	slices.SortFunc(files, func(a, b *ast.File) int {
+		return strings.Compare(pkg.Fset.Position(a.Pos()).Filename, pkg.Fset.Position(b.Pos()).Filename)
+	})
+
+	var routes []Route
+	var global []string
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+
+			groups := map[string]*routeGroup{}
+			for _, param := range fn.Type.Params.List {
+				name := typeName(param.Type)
+				for _, ident := range param.Names {
+					if name == "Router" {
+						groups[ident.Name] = &routeGroup{}
+					} else if strings.HasSuffix(name, "Group") {
+						groups[ident.Name] = &routeGroup{router: &routeGroup{}}
+					}
+				}
+			}
+
+			// Registrations already listed as the base of a UseBefore or
+			// UseAfter chain, which is visited first.
+			chained := map[*ast.CallExpr]bool{}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.AssignStmt:
+					name := routerAssignment(n)
+					if name == "" {
+						return true
+					}
+					call := n.Rhs[0].(*ast.CallExpr)
+					sel := call.Fun.(*ast.SelectorExpr)
+					if sel.Sel.Name == "Router" {
+						groups[name] = &routeGroup{}
+						return true
+					}
+					parent := groups[exprIdent(sel.X)]
+					if parent == nil || len(call.Args) == 0 {
+						return true
+					}
+					groups[name] = parent.group(routePathArg(pkg, call.Args[0]))
+				case *ast.CallExpr:
+					call, chain := routeChain(n)
+					if chained[call] {
+						return true
+					}
+					sel, ok := call.Fun.(*ast.SelectorExpr)
+					if !ok {
+						return true
+					}
+					group := groups[exprIdent(sel.X)]
+					if group == nil {
+						return true
+					}
+					switch sel.Sel.Name {
+					case "Use":
+						for _, arg := range call.Args {
+							global = append(global, routeFuncName(pkg, arg))
+						}
+						return true
+					case "UseBefore":
+						for _, arg := range call.Args {
+							group.before = append(group.before, routeFuncName(pkg, arg))
+						}
+						return true
+					case "UseAfter":
+						var after []string
+						for _, arg := range call.Args {
+							after = append(after, routeFuncName(pkg, arg))
+						}
+						group.after = append(after, group.after...)
+						return true
+					}
+					method, ok := routeMethods[sel.Sel.Name]
+					if !ok || len(call.Args) < 2 {
+						return true
+					}
+					chained[call] = true
+					before, after := group.middleware()
+					route := Route{
+						Method:     method,
+						Path:       joinRoutePath(group.prefix, routePathArg(pkg, call.Args[0])),
+						Handler:    routeFuncName(pkg, call.Args[len(call.Args)-1]),
+						Middleware: before,
+						After:      after,
+						Position:   relativePosition(pkg.Fset.Position(call.Pos()), dir),
+					}
+					for _, arg := range call.Args[1 : len(call.Args)-1] {
+						route.Middleware = append(route.Middleware, routeFuncName(pkg, arg))
+					}
+					for _, use := range chain {
+						var names []string
+						for _, arg := range use.Args {
+							names = append(names, routeFuncName(pkg, arg))
+						}
+						if use.Fun.(*ast.SelectorExpr).Sel.Name == "UseBefore" {
+							route.Middleware = append(route.Middleware, names...)
+						} else {
+							route.After = append(names, route.After...)
+						}
+					}
+					routes = append(routes, route)
+				}
+				return true
+			})
+		}
+	}
+
+	for i := range routes {
+		routes[i].Middleware = slices.Concat(global, routes[i].Middleware)
+		if routes[i].Middleware == nil {
+			routes[i].Middleware = []string{}
+		}
+		if routes[i].After == nil {
+			routes[i].After = []string{}
+		}
+	}
+	return routes
+}
+
+// routeChain unwraps the UseBefore and UseAfter calls chained to a call, as
+// in r.Get("/posts", h).UseBefore(auth). It returns the innermost call and
+// the chained calls in the order they run.
+func routeChain(call *ast.CallExpr) (*ast.CallExpr, []*ast.CallExpr) {
+	var chain []*ast.CallExpr
+	for {
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "UseBefore" && sel.Sel.Name != "UseAfter") {
+			break
+		}
+		inner, ok := sel.X.(*ast.CallExpr)
+		if !ok {
+			break
+		}
+		chain = append(chain, call)
+		call = inner
+	}
+	slices.Reverse(chain)
+	return call, chain
+}
+
+// routePathArg returns the path given to a route or group, which is a
+// string literal or, with type information, any string constant.
+func routePathArg(pkg *packages.Package, arg ast.Expr) string {
+	if pkg.TypesInfo != nil {
+		if tv, ok := pkg.TypesInfo.Types[arg]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+			return constant.StringVal(tv.Value)
+		}
+	}
+	if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		if path, err := strconv.Unquote(lit.Value); err == nil {
+			return path
+		}
+	}
+	return "{" + types.ExprString(arg) + "}"
+}
+
+// routeFuncName names a handler or middleware argument: handlers.PostIndexHandler
+// for functions, (*handlers.PostHandler).Index for methods, closure for
+// function literals and the expression itself for anything else.
+func routeFuncName(pkg *packages.Package, arg ast.Expr) string {
+	var ident *ast.Ident
+	switch e := arg.(type) {
+	case *ast.FuncLit:
+		return "closure"
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+	}
+	if ident != nil && pkg.TypesInfo != nil {
+		if fn, ok := pkg.TypesInfo.Uses[ident].(*types.Func); ok && fn.Pkg() != nil {
+			qualifier := func(p *types.Package) string { return p.Name() }
+			if recv := fn.Signature().Recv(); recv != nil {
+				return "(" + types.TypeString(recv.Type(), qualifier) + ")." + fn.Name()
+			}
+			return fn.Pkg().Name() + "." + fn.Name()
+		}
+	}
+	if call, ok := arg.(*ast.CallExpr); ok {
+		return types.ExprString(call.Fun) + "(...)"
+	}
+	return types.ExprString(arg)
+}
+
+// joinRoutePath appends path to the prefix of its group.
+func joinRoutePath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	if path == "" || path == "/" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func exprIdent(e ast.Expr) string {
+	if ident, ok := e.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// typeName returns the name of a possibly qualified or pointer type.
+func typeName(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.StarExpr:
+		return typeName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+func relativePosition(pos token.Position, dir string) string {
+	file := pos.Filename
+	if abs, err := filepath.Abs(dir); err == nil {
+		if rel, err := filepath.Rel(abs, file); err == nil {
+			file = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(file), pos.Line)
+}
+
+var routesJSON bool
+
+func init() {
+	routesCmd.Flags().BoolVar(&routesJSON, "json", false, "Print the routes as a JSON array")
+}
+
+var routesCmd = &cobra.Command{
+	Use:   "routes",
+	Short: "List the routes registered in internal/routes",
+	Long: `List the method, path, handler and middleware of every route registered in internal/routes. The route files are
+analyzed statically, so the app isn't started and no database is needed. Routes are found on variables assigned
+a.Router() or a Group(...) of one, including the prefix of their groups and the middleware added with Use, UseBefore
+and UseAfter on the router, its groups or the route itself.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isLemmegoProject() {
+			fmt.Println("Error: This does not appear to be a Lemmego project directory.")
+			os.Exit(1)
+		}
+
+		routes, err := ListRoutes(".")
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+
+		if routesJSON {
+			if routes == nil {
+				routes = []Route{}
+			}
+			out, err := json.MarshalIndent(routes, "", "  ")
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(out))
+			return
+		}
+
+		if len(routes) == 0 {
+			fmt.Println("No routes are registered.")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tMIDDLEWARE\tAFTER")
+		for _, r := range routes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Path, r.Handler, strings.Join(r.Middleware, ", "), strings.Join(r.After, ", "))
+		}
+		w.Flush()
+	},
+}
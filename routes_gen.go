@@ -0,0 +1,637 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// ResourceRoute is a single route registration for a generated handler.
+type ResourceRoute struct {
+	Method  string // Router method: Get, Post, Put, Delete
+	Path    string
+	Handler string
+}
+
+// resourceRoutes returns the routes for the handlers generated for name. REST
+// API projects have no use for the create and edit form handlers.
+func resourceRoutes(name string, preset ProjectPreset) []ResourceRoute {
+	strcase.ConfigureAcronym("id", "ID")
+	handler := "handlers." + strcase.ToCamel(name)
+	base := "/" + pluralize.NewClient().Plural(name)
+
+	routes := []ResourceRoute{
+		{"Get", base, handler + "IndexHandler"},
+		{"Get", base + "/create", handler + "CreateHandler"},
+		{"Post", base, handler + "StoreHandler"},
+		{"Get", base + "/{id}", handler + "ShowHandler"},
+		{"Get", base + "/{id}/edit", handler + "EditHandler"},
+		{"Put", base + "/{id}", handler + "UpdateHandler"},
+		{"Delete", base + "/{id}", handler + "DeleteHandler"},
+	}
+
+	if preset == PresetRESTAPI {
+		var apiRoutes []ResourceRoute
+		for _, r := range routes {
+			if !strings.HasSuffix(r.Handler, "CreateHandler") && !strings.HasSuffix(r.Handler, "EditHandler") {
+				apiRoutes = append(apiRoutes, r)
+			}
+		}
+		return apiRoutes
+	}
+
+	return routes
+}
+
+// routesFileFor returns the routes file and the function inside it that
+// handlers should be registered in for the given preset.
+func routesFileFor(preset ProjectPreset) (string, string) {
+	if preset == PresetMVC {
+		return filepath.Join("internal", "routes", "web.go"), "WebRoutes"
+	}
+	return filepath.Join("internal", "routes", "api.go"), "ApiRoutes"
+}
+
+// RouteOptions control where RegisterResourceRoutes registers the routes.
+type RouteOptions struct {
+	Prefix     string   // Path of a route group on the routes function's router
+	Middleware []string // Handlers run before each route, e.g. auth.Protected
+	Actions    []string // Handler actions to register; all of them when empty
+}
+
+// routePrefix normalizes a route group path to a leading slash and no
+// trailing one, or an empty string for no group.
+func routePrefix(prefix string) string {
+	if prefix = strings.Trim(prefix, "/"); prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// RegisterResourceRoutes adds the routes for the handlers generated for name
+// to the routes file of the project in the current directory, and returns the
+// path of that file.
+func RegisterResourceRoutes(name string, preset ProjectPreset, opts RouteOptions) (string, error) {
+	moduleName, err := GetModuleName()
+	if err != nil {
+		return "", err
+	}
+
+	file, funcName := routesFileFor(preset)
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	routes := resourceRoutes(name, preset)
+	if len(opts.Actions) > 0 {
+		routes = slices.DeleteFunc(routes, func(r ResourceRoute) bool {
+			return !slices.ContainsFunc(opts.Actions, func(action string) bool {
+				return strings.HasSuffix(r.Handler, strcase.ToCamel(action)+"Handler")
+			})
+		})
+	}
+
+	out, err := addRoutes(src, funcName, moduleName, routes, opts)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", file, err)
+	}
+
+	if !bytes.Equal(src, out) {
+		if err := updateGeneratedFile(filepath.ToSlash(file), out); err != nil {
+			return "", err
+		}
+	}
+
+	return file, nil
+}
+
+// unreferencedHandlers returns the names of the exported <Name>Handler
+// functions of internal/handlers that no file in internal/routes refers to.
+func unreferencedHandlers() ([]string, error) {
+	moduleName, err := GetModuleName()
+	if err != nil {
+		return nil, err
+	}
+	names, err := handlerFuncs(filepath.Join("internal", "handlers"))
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join("internal", "routes", "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := map[string]bool{}
+	for _, path := range files {
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		pkg := ""
+		for _, imp := range file.Imports {
+			if p, _ := strconv.Unquote(imp.Path.Value); p == moduleName+"/internal/handlers" {
+				pkg = "handlers"
+				if imp.Name != nil {
+					pkg = imp.Name.Name
+				}
+			}
+		}
+		if pkg == "" {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if x, ok := sel.X.(*ast.Ident); ok && x.Name == pkg {
+					referenced[sel.Sel.Name] = true
+				}
+			}
+			return true
+		})
+	}
+
+	var unreferenced []string
+	for _, name := range names {
+		if !referenced[name] {
+			unreferenced = append(unreferenced, name)
+		}
+	}
+	return unreferenced, nil
+}
+
+// handlerFuncs returns the names of the exported functions ending in Handler
+// that the non-test files of dir declare, in file and declaration order.
+func handlerFuncs(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.IsExported() && strings.HasSuffix(fn.Name.Name, "Handler") {
+				names = append(names, fn.Name.Name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// addRoutes adds the given routes to funcName in src, after the existing
+// registrations on the innermost router or route group, or on the group for
+// opts.Prefix, which is created when it doesn't exist yet. Routes whose
+// handler is already registered on that router or group are skipped, so the
+// result of running it twice is the same as running it once.
+func addRoutes(src []byte, funcName string, moduleName string, routes []ResourceRoute, opts RouteOptions) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var fn *ast.FuncDecl
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.FuncDecl); ok && d.Name.Name == funcName && d.Recv == nil {
+			fn = d
+			break
+		}
+	}
+	if fn == nil || fn.Body == nil {
+		return nil, fmt.Errorf("function %s not found", funcName)
+	}
+
+	router, block := findRouterBlock(fn)
+	if router == "" {
+		return nil, fmt.Errorf("no router found in %s", funcName)
+	}
+
+	middleware, err := resolveMiddleware(opts.Middleware, file, moduleName)
+	if err != nil {
+		return nil, err
+	}
+
+	target, parent := router, block
+	prefix := routePrefix(opts.Prefix)
+	if prefix != "" {
+		if name, groupBlock := findGroupBlock(fn, router, prefix); name != "" {
+			target, block = name, groupBlock
+		} else {
+			target, block = groupVarName(fn, prefix), nil
+		}
+	}
+
+	handlersPkg := importedAs(file, moduleName+"/internal/handlers", "handlers")
+	var registered map[string]bool
+	if block != nil {
+		registered = registeredHandlers(block, target, handlersPkg)
+	}
+
+	var stmts []ast.Stmt
+	for _, r := range routes {
+		pkg, handler, _ := strings.Cut(r.Handler, ".")
+		if pkg != "handlers" {
+			return nil, fmt.Errorf("unexpected handler %s", r.Handler)
+		}
+		if registered[handler] {
+			continue
+		}
+		args := []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(r.Path)}}
+		for _, m := range middleware {
+			args = append(args, m.expr())
+		}
+		args = append(args, selector(handlersPkg, handler))
+		stmts = append(stmts, &ast.ExprStmt{X: &ast.CallExpr{Fun: selector(target, r.Method), Args: args}})
+	}
+	if len(stmts) == 0 {
+		return src, nil
+	}
+
+	imports := []string{moduleName + "/internal/handlers"}
+	for _, m := range middleware {
+		if m.importPath != "" {
+			imports = append(imports, m.importPath)
+		}
+	}
+	for _, importPath := range imports {
+		if !importsPath(file, importPath) {
+			astutil.AddImport(fset, file, importPath)
+		}
+	}
+
+	if block == nil {
+		// The group goes after the routes of the router it belongs to.
+		block = parent
+		stmts = []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent(target)},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.CallExpr{
+					Fun:  selector(router, "Group"),
+					Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(prefix)}},
+				}},
+			},
+			&ast.BlockStmt{List: stmts},
+		}
+	}
+	// The new statements take the position they are appended at, after the
+	// last statement and its line comment, so that comments stay in place.
+	pos := block.Lbrace + 1
+	if len(block.List) > 0 {
+		pos = block.List[len(block.List)-1].End()
+		for _, cg := range file.Comments {
+			if cg.Pos() >= pos && fset.Position(cg.Pos()).Line == fset.Position(pos).Line {
+				pos = cg.End()
+			}
+		}
+	}
+	for _, stmt := range stmts {
+		setPos(stmt, pos)
+	}
+	block.List = append(block.List, stmts...)
+	ast.SortImports(fset, file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("formatting routes: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// findRouterBlock returns the name of the innermost router variable in fn
+// (a Router() or Group() result) and the block its routes are registered in.
+// A group only counts when it is the sole group of a router that has no
+// routes of its own, like the /api group of api.go, so groups added with a
+// prefix don't take over the routes of later resources.
+func findRouterBlock(fn *ast.FuncDecl) (string, *ast.BlockStmt) {
+	var router string
+	block := fn.Body
+
+	var visit func(b *ast.BlockStmt)
+	visit = func(b *ast.BlockStmt) {
+		for i, stmt := range b.List {
+			switch s := stmt.(type) {
+			case *ast.AssignStmt:
+				if name := routerAssignment(s); name != "" && (router == "" || isSoleGroup(s, router, b.List)) {
+					router, block = name, b
+					// Routes on a group usually live in the block that follows it.
+					if i+1 < len(b.List) {
+						if next, ok := b.List[i+1].(*ast.BlockStmt); ok {
+							block = next
+							visit(next)
+						}
+					}
+				}
+			case *ast.BlockStmt:
+				if router == "" {
+					visit(s)
+				}
+			}
+		}
+	}
+	visit(fn.Body)
+
+	return router, block
+}
+
+// findGroupBlock finds the route group created by router.Group(prefix) in fn
+// and returns its variable name and the block its routes are registered in.
+// The name is empty when there is no such group.
+func findGroupBlock(fn *ast.FuncDecl, router, prefix string) (string, *ast.BlockStmt) {
+	var visit func(b *ast.BlockStmt) (string, *ast.BlockStmt)
+	visit = func(b *ast.BlockStmt) (string, *ast.BlockStmt) {
+		for i, stmt := range b.List {
+			switch s := stmt.(type) {
+			case *ast.AssignStmt:
+				if groupAssignment(s, router) != prefix {
+					continue
+				}
+				name := s.Lhs[0].(*ast.Ident).Name
+				if i+1 < len(b.List) {
+					if next, ok := b.List[i+1].(*ast.BlockStmt); ok {
+						return name, next
+					}
+				}
+				return name, b
+			case *ast.BlockStmt:
+				if name, block := visit(s); name != "" {
+					return name, block
+				}
+			}
+		}
+		return "", nil
+	}
+	return visit(fn.Body)
+}
+
+// groupAssignment returns the prefix if s assigns router.Group(prefix) to a
+// variable, with the prefix normalized to a single leading slash.
+func groupAssignment(s *ast.AssignStmt, router string) string {
+	if routerAssignment(s) == "" {
+		return ""
+	}
+	call := s.Rhs[0].(*ast.CallExpr)
+	sel := call.Fun.(*ast.SelectorExpr)
+	if x, ok := sel.X.(*ast.Ident); !ok || x.Name != router || sel.Sel.Name != "Group" || len(call.Args) == 0 {
+		return ""
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	path, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return routePrefix(path)
+}
+
+// groupVarName returns a variable name for the group of prefix that isn't
+// used in fn yet, e.g. adminGroup for /admin.
+func groupVarName(fn *ast.FuncDecl, prefix string) string {
+	words := strings.FieldsFunc(prefix, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	base := strcase.ToLowerCamel(strings.Join(words, "_")) + "Group"
+	if !unicode.IsLetter(rune(base[0])) {
+		base = "g" + strings.ToUpper(base[:1]) + base[1:]
+	}
+
+	used := map[string]bool{}
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			used[ident.Name] = true
+		}
+		return true
+	})
+	name := base
+	for i := 2; used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	return name
+}
+
+// routeMiddleware is a middleware handler passed to route registrations.
+type routeMiddleware struct {
+	pkg        string // Name of the package the routes file refers to it by; empty for the routes package
+	name       string
+	importPath string // Import the routes file needs, if any
+}
+
+func (m routeMiddleware) expr() ast.Expr {
+	if m.pkg == "" {
+		return ast.NewIdent(m.name)
+	}
+	return selector(m.pkg, m.name)
+}
+
+// knownMiddlewarePackages are the packages middleware can be given from by
+// name alone. The project's own internal/middleware package is added by
+// resolveMiddleware, and takes the middleware name over lemmego/api's
+// package, which has to be given by import path.
+var knownMiddlewarePackages = map[string]string{
+	"auth": "github.com/lemmego/auth",
+}
+
+// resolveMiddleware turns the --middleware values into expressions for the
+// routes file. A value is a function of the routes package (Logged), a
+// function of a package the file imports or that is known by name
+// (auth.Protected, or middleware.Admin for the project's internal/middleware),
+// or a function of any package given by import path
+// (github.com/lemmego/api/middleware.VerifyCSRF).
+func resolveMiddleware(values []string, file *ast.File, moduleName string) ([]routeMiddleware, error) {
+	imported := map[string]string{}
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		name := path.Base(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imported[name] = p
+	}
+
+	var middleware []routeMiddleware
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		qualifier, name, ok := cutLast(value, ".")
+		if !ok {
+			qualifier, name = "", value
+		}
+		if !token.IsIdentifier(name) {
+			return nil, fmt.Errorf("invalid middleware %q", value)
+		}
+		if qualifier == "" {
+			middleware = append(middleware, routeMiddleware{name: name})
+			continue
+		}
+
+		if strings.Contains(qualifier, "/") {
+			pkg := importedAs(file, qualifier, path.Base(qualifier))
+			if !token.IsIdentifier(pkg) {
+				return nil, fmt.Errorf("invalid middleware %q", value)
+			}
+			middleware = append(middleware, routeMiddleware{pkg, name, qualifier})
+			continue
+		}
+
+		if _, ok := imported[qualifier]; ok {
+			middleware = append(middleware, routeMiddleware{pkg: qualifier, name: name})
+			continue
+		}
+		importPath, ok := knownMiddlewarePackages[qualifier]
+		if qualifier == "middleware" {
+			importPath, ok = moduleName+"/internal/middleware", true
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown package %q in middleware %q, give it by import path instead", qualifier, value)
+		}
+		middleware = append(middleware, routeMiddleware{importedAs(file, importPath, qualifier), name, importPath})
+	}
+	return middleware, nil
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// isSoleGroup reports whether s assigns router.Group(...) and that group is
+// the only thing router is used for in list.
+func isSoleGroup(s *ast.AssignStmt, router string, list []ast.Stmt) bool {
+	sel := s.Rhs[0].(*ast.CallExpr).Fun.(*ast.SelectorExpr)
+	if x, ok := sel.X.(*ast.Ident); !ok || x.Name != router || sel.Sel.Name != "Group" {
+		return false
+	}
+	calls := 0
+	for _, stmt := range list {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+					if x, ok := sel.X.(*ast.Ident); ok && x.Name == router {
+						calls++
+					}
+				}
+			}
+			return true
+		})
+	}
+	return calls == 1
+}
+
+// routerAssignment returns the variable name if s assigns the result of a
+// Router() or Group() call, e.g. r := a.Router().
+func routerAssignment(s *ast.AssignStmt) string {
+	if len(s.Lhs) != 1 || len(s.Rhs) != 1 {
+		return ""
+	}
+	ident, ok := s.Lhs[0].(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	call, ok := s.Rhs[0].(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || (sel.Sel.Name != "Router" && sel.Sel.Name != "Group") {
+		return ""
+	}
+	return ident.Name
+}
+
+// registeredHandlers collects the names of the handlers of package pkg that
+// are passed to a call on router in block, such as PostIndexHandler for
+// r.Get("/posts", handlers.PostIndexHandler).
+func registeredHandlers(block *ast.BlockStmt, router, pkg string) map[string]bool {
+	registered := map[string]bool{}
+	ast.Inspect(block, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); !ok || x.Name != router {
+			return true
+		}
+		for _, arg := range call.Args {
+			if sel, ok := arg.(*ast.SelectorExpr); ok {
+				if x, ok := sel.X.(*ast.Ident); ok && x.Name == pkg {
+					registered[sel.Sel.Name] = true
+				}
+			}
+		}
+		return true
+	})
+	return registered
+}
+
+// importedAs returns the name file refers to importPath by, or name when it
+// doesn't import it yet.
+func importedAs(file *ast.File, importPath, name string) string {
+	for _, imp := range file.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == importPath {
+			return importName(imp)
+		}
+	}
+	return name
+}
+
+// importsPath reports whether file imports importPath.
+func importsPath(file *ast.File, importPath string) bool {
+	for _, imp := range file.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == importPath {
+			return true
+		}
+	}
+	return false
+}
+
+// selector returns the expression x.sel.
+func selector(x, sel string) *ast.SelectorExpr {
+	return &ast.SelectorExpr{X: ast.NewIdent(x), Sel: ast.NewIdent(sel)}
+}
+
+// setPos sets the positions of node and the nodes inside it to pos. The
+// position of a call's ellipsis is left unset, as it marks a variadic call.
+func setPos(node ast.Node, pos token.Pos) {
+	posType := reflect.TypeOf(token.NoPos)
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		v := reflect.ValueOf(n).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.Type() == posType && v.Type().Field(i).Name != "Ellipsis" {
+				f.Set(reflect.ValueOf(pos))
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+const testWebRoutes = `package routes
+
+import (
+	"github.com/lemmego/api/app"
+	"github.com/lemmego/api/res"
+)
+
+func WebRoutes(a app.App) {
+	r := a.Router()
+	r.Get("/{$}", func(c app.Context) error {
+		return c.Render(res.NewTemplate(c, "index.page.gohtml"))
+	})
+}
+`
+
+const testAPIRoutes = `package routes
+
+import "github.com/lemmego/api/app"
+
+func ApiRoutes(a app.App) {
+	r := a.Router()
+	apiGroup := r.Group("/api")
+	{
+		// Health check
+		apiGroup.Get("/ping", func(c app.Context) error {
+			return app.M{"message": "pong"}
+		})
+	}
+}
+`
+
+func TestAddRoutesWeb(t *testing.T) {
+	routes := resourceRoutes("blog_post", PresetMVC)
+	if len(routes) != 7 {
+		t.Fatalf("expected 7 web routes, got %d", len(routes))
+	}
+
+	out, err := addRoutes([]byte(testWebRoutes), "WebRoutes", "example.com/app", routes, RouteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+
+	for _, want := range []string{
+		`"example.com/app/internal/handlers"`,
+		`r.Get("/blog_posts", handlers.BlogPostIndexHandler)`,
+		`r.Get("/blog_posts/{id}/edit", handlers.BlogPostEditHandler)`,
+		`r.Put("/blog_posts/{id}", handlers.BlogPostUpdateHandler)`,
+		`r.Delete("/blog_posts/{id}", handlers.BlogPostDeleteHandler)`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %s\n%s", want, got)
+		}
+	}
+
+	again, err := addRoutes(out, "WebRoutes", "example.com/app", routes, RouteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != got {
+		t.Errorf("expected adding routes twice to be a no-op\n%s", again)
+	}
+}
+
+func TestAddRoutesAPIGroup(t *testing.T) {
+	routes := resourceRoutes("post", PresetRESTAPI)
+	if len(routes) != 5 {
+		t.Fatalf("expected 5 api routes, got %d", len(routes))
+	}
+
+	out, err := addRoutes([]byte(testAPIRoutes), "ApiRoutes", "example.com/app", routes, RouteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+
+	if !strings.Contains(got, "\t\tapiGroup.Post(\"/posts\", handlers.PostStoreHandler)\n") {
+		t.Errorf("expected routes inside the api group block\n%s", got)
+	}
+	if !strings.Contains(got, "// Health check") {
+		t.Errorf("expected comments to be preserved\n%s", got)
+	}
+	if !strings.Contains(got, "import (\n\t\"example.com/app/internal/handlers\"\n\t\"github.com/lemmego/api/app\"\n)") {
+		t.Errorf("expected a grouped import\n%s", got)
+	}
+}
+
+func TestAddRoutesExistingHandlers(t *testing.T) {
+	src := `package routes
+
+import (
+	"github.com/lemmego/api/app"
+	h "example.com/app/internal/handlers"
+)
+
+func WebRoutes(a app.App) {
+	r := a.Router()
+	r.Get("/articles", h.PostIndexHandler) // renamed path
+}
+`
+	out, err := addRoutes([]byte(src), "WebRoutes", "example.com/app", resourceRoutes("post", PresetMVC)[:2], RouteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	if strings.Contains(got, `"/posts"`) || strings.Count(got, "internal/handlers") != 1 {
+		t.Errorf("expected the registered handler and the handlers import to be reused\n%s", got)
+	}
+	if !strings.Contains(got, "\tr.Get(\"/articles\", h.PostIndexHandler) // renamed path\n\tr.Get(\"/posts/create\", h.PostCreateHandler)\n}") {
+		t.Errorf("expected the create route after the existing one, under the import's name\n%s", got)
+	}
+}
+
+func TestAddRoutesMissingFunc(t *testing.T) {
+	if _, err := addRoutes([]byte(testAPIRoutes), "WebRoutes", "x", nil, RouteOptions{}); err == nil {
+		t.Error("expected an error when the routes function is missing")
+	}
+}
+
+func TestAddRoutesPrefixAndMiddleware(t *testing.T) {
+	routes := resourceRoutes("post", PresetMVC)
+	opts := RouteOptions{Prefix: "/admin/", Middleware: []string{"auth.Protected", "middleware.Admin"}}
+
+	out, err := addRoutes([]byte(testWebRoutes), "WebRoutes", "example.com/app", routes, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+
+	for _, want := range []string{
+		`"github.com/lemmego/auth"`,
+		`"example.com/app/internal/middleware"`,
+		"\tadminGroup := r.Group(\"/admin\")\n\t{\n",
+		"\t\tadminGroup.Get(\"/posts\", auth.Protected, middleware.Admin, handlers.PostIndexHandler)\n",
+		`adminGroup.Delete("/posts/{id}", auth.Protected, middleware.Admin, handlers.PostDeleteHandler)`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %s\n%s", want, got)
+		}
+	}
+
+	again, err := addRoutes(out, "WebRoutes", "example.com/app", routes, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != got {
+		t.Errorf("expected adding routes twice to be a no-op\n%s", again)
+	}
+
+	// Another resource in the same group goes into the existing block.
+	out, err = addRoutes(out, "WebRoutes", "example.com/app", resourceRoutes("tag", PresetMVC), RouteOptions{Prefix: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(out), ".Group(") != 1 || !strings.Contains(string(out), "\t\tadminGroup.Delete(\"/tags/{id}\", handlers.TagDeleteHandler)\n\t}") {
+		t.Errorf("expected the tag routes at the end of the admin group\n%s", out)
+	}
+}
+
+func TestAddRoutesPrefixInAPIGroup(t *testing.T) {
+	out, err := addRoutes([]byte(testAPIRoutes), "ApiRoutes", "example.com/app", resourceRoutes("post", PresetRESTAPI), RouteOptions{Prefix: "v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "\t\tv2Group := apiGroup.Group(\"/v2\")\n\t\t{\n\t\t\tv2Group.Get(\"/posts\", handlers.PostIndexHandler)") {
+		t.Errorf("expected a v2 group nested in the api group\n%s", out)
+	}
+}
+
+func TestAddRoutesAPIMiddlewareByImportPath(t *testing.T) {
+	opts := RouteOptions{Middleware: []string{"github.com/lemmego/api/middleware.VerifyCSRF"}}
+	out, err := addRoutes([]byte(testWebRoutes), "WebRoutes", "example.com/app", resourceRoutes("post", PresetMVC), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	if !strings.Contains(got, `"github.com/lemmego/api/middleware"`) || strings.Contains(got, "internal/middleware\"") {
+		t.Errorf("expected lemmego/api's middleware package only\n%s", got)
+	}
+	if !strings.Contains(got, `r.Get("/posts", middleware.VerifyCSRF, handlers.PostIndexHandler)`) {
+		t.Errorf("expected the middleware before the handler\n%s", got)
+	}
+}
+
+func TestAddRoutesUnknownMiddlewarePackage(t *testing.T) {
+	_, err := addRoutes([]byte(testWebRoutes), "WebRoutes", "example.com/app", resourceRoutes("post", PresetMVC), RouteOptions{Middleware: []string{"acl.Check"}})
+	if err == nil || !strings.Contains(err.Error(), `unknown package "acl"`) {
+		t.Errorf("expected an unknown package error, got %v", err)
+	}
+}
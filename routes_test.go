@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testRoutesProject is a module with a routes package that mimics the
+// lemmego router without depending on it, so that it type-checks offline.
+var testRoutesProject = map[string]string{
+	"go.mod": "module example.com/app\n\ngo 1.22\n",
+	"internal/handlers/handlers.go": `package handlers
+
+type Context interface{}
+
+func PostIndexHandler(c Context) error { return nil }
+
+type PostHandler struct{}
+
+func (h *PostHandler) Delete(c Context) error { return nil }
+`,
+	"internal/routes/router.go": `package routes
+
+type App struct{}
+
+func (App) Router() *Router { return &Router{} }
+
+type Handler func(c any) error
+
+type Router struct{}
+
+func (r *Router) Group(prefix string) *RouteGroup                   { return &RouteGroup{} }
+func (r *Router) Use(middlewares ...func(any) any)                  {}
+func (r *Router) UseBefore(handlers ...Handler)                     {}
+func (r *Router) UseAfter(handlers ...Handler)                      {}
+func (r *Router) Get(pattern string, handlers ...Handler) *Route    { return &Route{} }
+func (r *Router) Post(pattern string, handlers ...Handler) *Route   { return &Route{} }
+func (r *Router) Delete(pattern string, handlers ...Handler) *Route { return &Route{} }
+
+type RouteGroup struct{}
+
+func (g *RouteGroup) Group(prefix string) *RouteGroup                   { return g }
+func (g *RouteGroup) UseBefore(handlers ...Handler)                     {}
+func (g *RouteGroup) UseAfter(handlers ...Handler)                      {}
+func (g *RouteGroup) Get(pattern string, handlers ...Handler) *Route    { return &Route{} }
+func (g *RouteGroup) Post(pattern string, handlers ...Handler) *Route   { return &Route{} }
+func (g *RouteGroup) Delete(pattern string, handlers ...Handler) *Route { return &Route{} }
+
+type Route struct{}
+
+func (r *Route) UseBefore(handlers ...Handler) *Route { return r }
+func (r *Route) UseAfter(handlers ...Handler) *Route  { return r }
+
+func Logged(next any) any { return next }
+func Auth(c any) error    { return nil }
+func Admin(c any) error   { return nil }
+func Audit(c any) error   { return nil }
+func Flush(c any) error   { return nil }
+`,
+	"internal/routes/web.go": `package routes
+
+import "example.com/app/internal/handlers"
+
+const adminPrefix = "/admin"
+
+func WebRoutes(a App) {
+	r := a.Router()
+	r.Get("/{$}", func(c any) error {
+		return nil
+	})
+	r.UseAfter(Flush)
+
+	apiGroup := r.Group("/api")
+	apiGroup.UseBefore(Auth)
+	{
+		apiGroup.Get("/posts", handlers.PostIndexHandler)
+		apiGroup.Delete("/posts/{id}", Auth, (&handlers.PostHandler{}).Delete).UseBefore(Admin).UseAfter(Audit)
+	}
+
+	admin := apiGroup.Group(adminPrefix)
+	admin.UseAfter(Audit)
+	admin.Post("/", handlers.PostIndexHandler)
+	r.Use(Logged)
+}
+`,
+}
+
+func TestListRoutes(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range testRoutesProject {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	routes, err := ListRoutes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Route{
+		{"GET", "/{$}", "closure", []string{"routes.Logged"}, []string{}, "internal/routes/web.go:9"},
+		{"GET", "/api/posts", "handlers.PostIndexHandler", []string{"routes.Logged", "routes.Auth"}, []string{"routes.Flush"}, "internal/routes/web.go:17"},
+		{"DELETE", "/api/posts/{id}", "(*handlers.PostHandler).Delete", []string{"routes.Logged", "routes.Auth", "routes.Auth", "routes.Admin"}, []string{"routes.Audit", "routes.Flush"}, "internal/routes/web.go:18"},
+		{"POST", "/api/admin", "handlers.PostIndexHandler", []string{"routes.Logged", "routes.Auth"}, []string{"routes.Audit", "routes.Flush"}, "internal/routes/web.go:23"},
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("expected routes\n%v\ngot\n%v", want, routes)
+	}
+}
+
+func TestJoinRoutePath(t *testing.T) {
+	for _, tt := range []struct{ prefix, path, want string }{
+		{"", "/posts", "/posts"},
+		{"/api", "/posts", "/api/posts"},
+		{"/api/", "posts", "/api/posts"},
+		{"/admin", "/", "/admin"},
+	} {
+		if got := joinRoutePath(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("joinRoutePath(%q, %q) = %q, want %q", tt.prefix, tt.path, got, tt.want)
+		}
+	}
+}
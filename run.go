@@ -25,12 +25,13 @@ var runCmd = &cobra.Command{
 
 		// Only build frontend assets for commands that serve HTTP
 		if needsFrontend(args) {
-			if hasTemplFiles() {
+			pm := currentProjectManifest()
+			if projectUsesTempl(pm) {
 				EnsureBinary("templ")
 				fmt.Println("> Generating templ files...")
 				RunCommand(".", "templ", "generate")
 			}
-			if fileExists("package.json") {
+			if projectHasNodeDeps(pm) {
 				EnsureBinary("node")
 				fmt.Println("> Building frontend assets...")
 				RunCommand(".", npmBinary(), "run", "build")
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	scaffoldCmd.AddCommand(scaffoldStatusCmd)
+	scaffoldCmd.AddCommand(scaffoldChecksumsCmd)
+}
+
+var scaffoldCmd = &cobra.Command{
+	Use:   "scaffold",
+	Short: "Inspect the scaffold new projects are created from",
+}
+
+var scaffoldStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which scaffold source and version is used",
+	Run: func(cmd *cobra.Command, args []string) {
+		if pm := currentProjectManifest(); pm != nil {
+			fmt.Printf("Project:    created from the %s with lemmego %s\n", pm.Scaffold, pm.CLIVersion)
+		}
+		if origin, err := lastScaffoldOrigin(); err == nil {
+			fmt.Printf("Last used:  %s on %s\n", origin, origin.UsedAt.Format("2006-01-02 15:04"))
+		} else {
+			fmt.Println("Last used:  no project has been created yet")
+		}
+
+		_, embedded := embeddedScaffold()
+		fmt.Printf("Built-in:   version %s\n", embedded.Version)
+
+		_, cached, err := cachedScaffold(scaffoldRepoBranch)
+		switch {
+		case err == nil:
+			fmt.Printf("Latest:     version %s, verified, at %s\n", cached.Version, cached.Path)
+		case errors.Is(err, fs.ErrNotExist):
+			fmt.Println("Latest:     not cached, the built-in scaffold is used")
+		default:
+			fmt.Printf("Latest:     %v, the built-in scaffold is used\n", err)
+		}
+	},
+}
+
+var scaffoldChecksumsCmd = &cobra.Command{
+	Use:   "checksums [dir]",
+	Short: "Write the SHA256SUMS file of a scaffold",
+	Long: `Write the SHA256SUMS file listing every file of the scaffold in dir (default _scaffold).
+Fetched scaffolds are verified against it before they are used, so it must be updated with every change to the scaffold.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "_scaffold"
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		sums, err := scaffoldChecksums(os.DirFS(dir))
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		target := filepath.Join(dir, scaffoldChecksumFile)
+		if err := os.WriteFile(target, formatChecksums(sums), 0644); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Wrote checksums of %d files to %s\n", len(sums), target)
+	},
+}
@@ -38,15 +38,6 @@ type scaffoldSource struct {
 	prefix string
 }
 
-// resolveScaffoldSource returns the scaffold source, preferring the cached
-// scaffold on disk over the embedded one.
-func resolveScaffoldSource() scaffoldSource {
-	if dir := scaffoldDir(); dir != "" {
-		return scaffoldSource{fs: os.DirFS(dir), prefix: ""}
-	}
-	return scaffoldSource{fs: scaffoldEmbedFS, prefix: "_scaffold"}
-}
-
 // loadVersions reads versions.json from the scaffold source.
 // Returns nil if unavailable (offline), so Version() falls back to "latest".
 func loadVersions(src scaffoldSource) map[string]string {
@@ -63,29 +54,37 @@ func loadVersions(src scaffoldSource) map[string]string {
 }
 
 func ScaffoldProject(cfg ProjectConfig, destDir string) error {
-	fetchLatestScaffold()
-	src := resolveScaffoldSource()
+	src, origin, err := resolveScaffold("")
+	if err != nil {
+		return err
+	}
+	return scaffoldProjectFrom(src, origin, cfg, destDir)
+}
+
+// scaffoldProjectFrom scaffolds a project from the given scaffold source and
+// records the config and the origin of the scaffold in lemmego.json.
+func scaffoldProjectFrom(src scaffoldSource, origin ScaffoldOrigin, cfg ProjectConfig, destDir string) error {
+	fmt.Println("> Scaffolding project...")
+	return renderScaffold(src, origin, cfg, destDir)
+}
 
+// renderScaffold writes the files of the scaffold for cfg to destDir.
+func renderScaffold(src scaffoldSource, origin ScaffoldOrigin, cfg ProjectConfig, destDir string) error {
+	m, err := loadScaffoldManifest(src)
+	if err != nil {
+		return err
+	}
+
+	cfg.Options = m.withOptionalDefaults(cfg.values())
 	td := buildTemplateData(cfg)
 	td.versions = loadVersions(src)
-	overlays := resolveOverlays(cfg)
-
-	fmt.Println("> Scaffolding project...")
+	overlays, stubs := m.Resolve(cfg.values())
 
 	if err := copyBaseFiles(destDir, src); err != nil {
 		return fmt.Errorf("copying base files: %w", err)
 	}
 
-	ensureDirs(destDir,
-		"internal/commands",
-		"internal/handlers",
-		"internal/middleware",
-		"internal/models",
-		"internal/plugins",
-		"public",
-		"storage",
-		"storage/session",
-	)
+	ensureDirs(destDir, m.Dirs...)
 
 	for _, overlay := range overlays {
 		if err := applyOverlay(overlay, destDir, src); err != nil {
@@ -93,10 +92,14 @@ func ScaffoldProject(cfg ProjectConfig, destDir string) error {
 		}
 	}
 
-	if err := generateDynamicFiles(td, destDir, src); err != nil {
+	if err := generateDynamicFiles(td, stubs, destDir, src); err != nil {
 		return fmt.Errorf("generating dynamic files: %w", err)
 	}
 
+	if err := writeProjectManifest(destDir, newProjectManifest(cfg, origin)); err != nil {
+		return fmt.Errorf("writing %s: %w", projectManifestFile, err)
+	}
+
 	return nil
 }
 
@@ -116,49 +119,6 @@ func buildTemplateData(cfg ProjectConfig) templateData {
 	return td
 }
 
-func resolveOverlays(cfg ProjectConfig) []string {
-	var overlays []string
-
-	if cfg.Preset == PresetMVC {
-		overlays = append(overlays, "overlays/mvc")
-
-		switch cfg.Frontend {
-		case FrontendGoTemplates:
-			overlays = append(overlays, "overlays/frontend_go_templates")
-		case FrontendTempl:
-			overlays = append(overlays, "overlays/frontend_templ")
-		case FrontendInertiaReact:
-			overlays = append(overlays, "overlays/frontend_inertia_react")
-		case FrontendInertiaVue:
-			overlays = append(overlays, "overlays/frontend_inertia_vue")
-		case FrontendTemplInertiaReact:
-			overlays = append(overlays, "overlays/frontend_templ_inertia_react")
-		case FrontendTemplInertiaVue:
-			overlays = append(overlays, "overlays/frontend_templ_inertia_vue")
-		}
-	} else {
-		overlays = append(overlays, "overlays/rest_api")
-	}
-
-	if cfg.EnableAuth {
-		if cfg.ORM == OrmGORM {
-			if cfg.EnableGPA {
-				overlays = append(overlays, "overlays/auth_gorm_gpa")
-			} else {
-				overlays = append(overlays, "overlays/auth_gorm")
-			}
-		} else {
-			if cfg.EnableGPA {
-				overlays = append(overlays, "overlays/auth_bun_gpa")
-			} else {
-				overlays = append(overlays, "overlays/auth_bun")
-			}
-		}
-	}
-
-	return overlays
-}
-
 func copyBaseFiles(destDir string, src scaffoldSource) error {
 	root := path.Join(src.prefix, "base")
 
@@ -228,30 +188,9 @@ func applyOverlay(overlayPath string, destDir string, src scaffoldSource) error
 	})
 }
 
-func generateDynamicFiles(td templateData, destDir string, src scaffoldSource) error {
-	stubs := map[string]string{
-		"bootstrap/providers.go":         "providers.go.tpl",
-		"bootstrap/routes.go":            "routes_bootstrap.go.tpl",
-		"bootstrap/middleware.go":         "middleware.go.tpl",
-		"internal/configs/database.go":   "database.go.tpl",
-		"internal/configs/session.go":    "session.go.tpl",
-		"cmd/app/main.go":                "main.go.tpl",
-		"go.mod":                         "go.mod.tpl",
-		".env.example":                   "env.example.tpl",
-	}
-
-	if td.Preset == PresetMVC {
-		stubs["internal/routes/web.go"] = "web.go.tpl"
-	}
-
-	stubs["internal/routes/api.go"] = "api.go.tpl"
-
-	if td.Frontend.HasNodeDeps() {
-		stubs["pnpm-workspace.yaml"] = "pnpm-workspace.yaml.tpl"
-		stubs["package.json"] = "package.json.tpl"
-		stubs["vite.config.js"] = "vite.config.js.tpl"
-	}
-
+// generateDynamicFiles renders stubs, which map destination paths to stub
+// files under stubs/, into destDir.
+func generateDynamicFiles(td templateData, stubs map[string]string, destDir string, src scaffoldSource) error {
 	stubsDir := path.Join(src.prefix, "stubs")
 
 	for destRelPath, stubRelPath := range stubs {
@@ -276,7 +215,14 @@ func generateDynamicFiles(td templateData, destDir string, src scaffoldSource) e
 			return err
 		}
 
-		if err := os.WriteFile(destPath, buf.Bytes(), 0644); err != nil {
+		content := buf.Bytes()
+		if strings.HasSuffix(destRelPath, ".go") {
+			if content, err = formatGoFile(stubRelPath, destRelPath, content); err != nil {
+				return err
+			}
+		}
+
+		if err := os.WriteFile(destPath, content, 0644); err != nil {
 			return fmt.Errorf("writing %s: %w", destRelPath, err)
 		}
 	}
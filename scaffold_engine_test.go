@@ -131,7 +131,7 @@ func TestResolveOverlaysMVCDefault(t *testing.T) {
 		EnableRedis: false,
 		EnableAuth:  false,
 	}
-	overlays := resolveOverlays(cfg)
+	overlays, _ := testManifest(t).Resolve(cfg.values())
 	if len(overlays) == 0 {
 		t.Fatal("expected at least one overlay")
 	}
@@ -144,7 +144,7 @@ func TestResolveOverlaysWithAuth(t *testing.T) {
 		Frontend:    FrontendGoTemplates,
 		EnableAuth:  true,
 	}
-	overlays := resolveOverlays(cfg)
+	overlays, _ := testManifest(t).Resolve(cfg.values())
 	found := false
 	for _, o := range overlays {
 		if o == "overlays/auth_gorm" {
@@ -164,7 +164,7 @@ func TestResolveOverlaysWithAuthBunGPA(t *testing.T) {
 		EnableAuth:  true,
 		EnableGPA:   true,
 	}
-	overlays := resolveOverlays(cfg)
+	overlays, _ := testManifest(t).Resolve(cfg.values())
 	found := false
 	for _, o := range overlays {
 		if o == "overlays/auth_bun_gpa" {
@@ -203,6 +203,7 @@ func TestScaffoldProjectCreatesFiles(t *testing.T) {
 		"internal/configs/session.go",
 		"internal/commands/appkey.go",
 		"internal/commands/inspire.go",
+		"lemmego.json",
 	}
 
 	for _, f := range expectedFiles {
@@ -0,0 +1,480 @@
+package cli
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const scaffoldCacheDir = ".cache/lemmego/scaffold"
+const scaffoldRepoOwner = "lemmego"
+const scaffoldRepoName = "cli"
+const scaffoldRepoBranch = "main"
+
+// scaffoldChecksumFile lists the SHA-256 of every other file of a scaffold in
+// the format of sha256sum, with paths relative to the scaffold root.
+const scaffoldChecksumFile = "SHA256SUMS"
+
+var (
+	scaffoldRawURL     = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s", scaffoldRepoOwner, scaffoldRepoName)
+	scaffoldTarballURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/tarball", scaffoldRepoOwner, scaffoldRepoName)
+	// The timeouts keep a blocked network, e.g. on an air-gapped build agent,
+	// from hanging the CLI.
+	scaffoldHTTPClient = &http.Client{
+		Timeout: 2 * time.Minute,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 10 * time.Second}).DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 15 * time.Second,
+		},
+	}
+)
+
+// scaffoldOffline is set by --offline. LEMMEGO_OFFLINE=1 has the same effect.
+var scaffoldOffline bool
+
+// isOffline reports whether scaffolds must be used from the cache or the
+// binary without any network request.
+func isOffline() bool {
+	if scaffoldOffline {
+		return true
+	}
+	offline, _ := strconv.ParseBool(os.Getenv("LEMMEGO_OFFLINE"))
+	return offline
+}
+
+// ScaffoldOrigin describes where the scaffold of a project came from.
+type ScaffoldOrigin struct {
+	Source  string    `json:"source"` // embedded, cache or template
+	Ref     string    `json:"ref,omitempty"`
+	Version string    `json:"version,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	UsedAt  time.Time `json:"used_at,omitzero"`
+}
+
+func (o ScaffoldOrigin) String() string {
+	switch o.Source {
+	case "cache":
+		return fmt.Sprintf("cached scaffold %s (version %s) at %s", o.Ref, o.Version, o.Path)
+	case "template":
+		return fmt.Sprintf("template %s", o.Path)
+	}
+	return fmt.Sprintf("built-in scaffold (version %s)", o.Version)
+}
+
+// scaffoldCache returns the path to the local scaffold cache directory.
+func scaffoldCache() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, scaffoldCacheDir)
+}
+
+// scaffoldCacheEntry returns the directory caching the scaffold of ref, a
+// branch or tag of the CLI repository.
+func scaffoldCacheEntry(ref string) string {
+	cacheDir := scaffoldCache()
+	if cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(cacheDir, url.PathEscape(ref))
+}
+
+// scaffoldVersion returns the VERSION of the scaffold in src, or "".
+func scaffoldVersion(src scaffoldSource) string {
+	data, err := fs.ReadFile(src.fs, path.Join(src.prefix, "VERSION"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func embeddedScaffold() (scaffoldSource, ScaffoldOrigin) {
+	src := scaffoldSource{fs: scaffoldEmbedFS, prefix: "_scaffold"}
+	return src, ScaffoldOrigin{Source: "embedded", Version: scaffoldVersion(src)}
+}
+
+// cachedScaffold returns the cached scaffold of ref after verifying it
+// against its checksums. The error wraps fs.ErrNotExist when ref is not
+// cached.
+func cachedScaffold(ref string) (scaffoldSource, ScaffoldOrigin, error) {
+	dir := scaffoldCacheEntry(ref)
+	if dir == "" {
+		return scaffoldSource{}, ScaffoldOrigin{}, fmt.Errorf("scaffold %s: %w", ref, fs.ErrNotExist)
+	}
+	root := filepath.Join(dir, "_scaffold")
+	if !dirExists(root) {
+		return scaffoldSource{}, ScaffoldOrigin{}, fmt.Errorf("scaffold %s is not cached: %w", ref, fs.ErrNotExist)
+	}
+	if err := verifyScaffoldDir(root); err != nil {
+		return scaffoldSource{}, ScaffoldOrigin{}, fmt.Errorf("cached scaffold %s failed verification: %w", ref, err)
+	}
+
+	src := scaffoldSource{fs: os.DirFS(root), prefix: ""}
+	return src, ScaffoldOrigin{Source: "cache", Ref: ref, Version: scaffoldVersion(src), Path: root}, nil
+}
+
+// resolveScaffold returns the scaffold to create a project from. A version
+// pins a tagged scaffold, which has to be fetched and verified. Without one,
+// the latest scaffold is fetched when possible, falling back to the cached
+// copy and then to the built-in scaffold with a warning. Offline, nothing is
+// fetched and a pinned version has to be cached already.
+func resolveScaffold(version string) (scaffoldSource, ScaffoldOrigin, error) {
+	if version != "" {
+		if isOffline() {
+			src, origin, err := cachedScaffold(version)
+			if errors.Is(err, fs.ErrNotExist) {
+				return src, origin, fmt.Errorf("scaffold %s is not cached, run `lemmego cache pull %s` before working offline", version, version)
+			}
+			return src, origin, err
+		}
+		if err := fetchScaffold(version); err != nil {
+			return scaffoldSource{}, ScaffoldOrigin{}, fmt.Errorf("fetching scaffold %s: %w", version, err)
+		}
+		return cachedScaffold(version)
+	}
+
+	if !isOffline() {
+		if err := fetchScaffold(scaffoldRepoBranch); err != nil {
+			fmt.Printf("Warning: could not update the scaffold: %v\n", err)
+		}
+	}
+
+	src, origin, err := cachedScaffold(scaffoldRepoBranch)
+	if err == nil {
+		return src, origin, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		fmt.Printf("Warning: %v, using the built-in scaffold\n", err)
+	}
+	src, origin = embeddedScaffold()
+	return src, origin, nil
+}
+
+// cachedScaffoldRefs returns the refs of the scaffolds in the cache, sorted.
+func cachedScaffoldRefs() []string {
+	cacheDir := scaffoldCache()
+	if cacheDir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return nil
+	}
+
+	var refs []string
+	for _, entry := range entries {
+		// Skip files and the temporary directories of unfinished fetches.
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if !dirExists(filepath.Join(cacheDir, entry.Name(), "_scaffold")) {
+			continue
+		}
+		ref, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	slices.Sort(refs)
+	return refs
+}
+
+// fetchScaffold downloads the scaffold of ref into the cache, unless a
+// verified copy is already there and is current. The download is checked
+// against the SHA256SUMS published for ref before it replaces the cached copy.
+func fetchScaffold(ref string) error {
+	if ref == "" || ref == "." || ref == ".." {
+		return fmt.Errorf("invalid scaffold version %q", ref)
+	}
+	if isOffline() {
+		return fmt.Errorf("cannot fetch scaffold %s while offline", ref)
+	}
+	dest := scaffoldCacheEntry(ref)
+	if dest == "" {
+		return errors.New("could not determine the scaffold cache directory")
+	}
+
+	if _, cached, err := cachedScaffold(ref); err == nil {
+		// Tags do not move, only the branch has to be compared.
+		if ref != scaffoldRepoBranch {
+			return nil
+		}
+		remote, err := httpGetBytes(scaffoldFileURL(ref, "VERSION"))
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(remote)) == cached.Version {
+			return nil
+		}
+	}
+
+	sumsData, err := httpGetBytes(scaffoldFileURL(ref, scaffoldChecksumFile))
+	if err != nil {
+		return fmt.Errorf("fetching checksums: %w", err)
+	}
+	sums, err := parseChecksums(sumsData)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpGet(scaffoldTarballURL + "/" + url.PathEscape(ref))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dest), ".fetch-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractScaffoldTarball(resp.Body, tmpDir); err != nil {
+		return fmt.Errorf("extracting scaffold %s: %w", ref, err)
+	}
+	root := filepath.Join(tmpDir, "_scaffold")
+	if err := verifyScaffold(os.DirFS(root), sums); err != nil {
+		return fmt.Errorf("scaffold %s does not match its checksums: %w", ref, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, scaffoldChecksumFile), sumsData, 0644); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, dest)
+}
+
+func scaffoldFileURL(ref, name string) string {
+	return fmt.Sprintf("%s/%s/_scaffold/%s", scaffoldRawURL, url.PathEscape(ref), name)
+}
+
+// httpGet fetches rawURL, failing on any status other than 200 OK.
+func httpGet(rawURL string) (*http.Response, error) {
+	resp, err := scaffoldHTTPClient.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return resp, nil
+}
+
+func httpGetBytes(rawURL string) ([]byte, error) {
+	resp, err := httpGet(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// extractScaffoldTarball extracts the _scaffold directory of a gzipped
+// repository tarball into dest. Like the GitHub tarballs, the archive has a
+// single top-level directory, which is stripped.
+func extractScaffoldTarball(r io.Reader, dest string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	found := false
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		_, name, ok := strings.Cut(hdr.Name, "/")
+		name = strings.TrimSuffix(name, "/")
+		if !ok || (name != "_scaffold" && !strings.HasPrefix(name, "_scaffold/")) {
+			continue
+		}
+		if !fs.ValidPath(name) {
+			return fmt.Errorf("unsafe path %q in archive", hdr.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			// Links and other special files have no place in a scaffold.
+			continue
+		}
+		found = true
+	}
+
+	if !found {
+		return errors.New("the archive has no _scaffold directory")
+	}
+	return nil
+}
+
+// scaffoldChecksums returns the SHA-256 of every file of the scaffold in
+// fsys, keyed by slash-separated path, leaving out the checksum file itself.
+func scaffoldChecksums(fsys fs.FS) (map[string]string, error) {
+	sums := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || p == scaffoldChecksumFile {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		sums[p] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	return sums, err
+}
+
+func parseChecksums(data []byte) (map[string]string, error) {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		sum, name, ok := strings.Cut(text, "  ")
+		if !ok || len(sum) != sha256.Size*2 || !fs.ValidPath(name) {
+			return nil, fmt.Errorf("%s: malformed line %d", scaffoldChecksumFile, line)
+		}
+		sums[name] = sum
+	}
+	return sums, scanner.Err()
+}
+
+func formatChecksums(sums map[string]string) []byte {
+	var buf bytes.Buffer
+	for _, name := range slices.Sorted(maps.Keys(sums)) {
+		fmt.Fprintf(&buf, "%s  %s\n", sums[name], name)
+	}
+	return buf.Bytes()
+}
+
+// verifyScaffold reports files of the scaffold in fsys that are missing,
+// modified or not listed in sums.
+func verifyScaffold(fsys fs.FS, sums map[string]string) error {
+	actual, err := scaffoldChecksums(fsys)
+	if err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(sums)) {
+		got, ok := actual[name]
+		if !ok {
+			return fmt.Errorf("%s is missing", name)
+		}
+		if got != sums[name] {
+			return fmt.Errorf("%s has been modified", name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(actual)) {
+		if _, ok := sums[name]; !ok {
+			return fmt.Errorf("%s is not listed in %s", name, scaffoldChecksumFile)
+		}
+	}
+	return nil
+}
+
+// verifyScaffoldDir verifies the scaffold at root against its own checksum
+// file.
+func verifyScaffoldDir(root string) error {
+	data, err := os.ReadFile(filepath.Join(root, scaffoldChecksumFile))
+	if err != nil {
+		return err
+	}
+	sums, err := parseChecksums(data)
+	if err != nil {
+		return err
+	}
+	return verifyScaffold(os.DirFS(root), sums)
+}
+
+// recordScaffoldOrigin remembers the scaffold a project was created from, for
+// `lemmego scaffold status`.
+func recordScaffoldOrigin(origin ScaffoldOrigin) error {
+	cacheDir := scaffoldCache()
+	if cacheDir == "" {
+		return errors.New("could not determine the scaffold cache directory")
+	}
+	origin.UsedAt = time.Now()
+	data, err := json.MarshalIndent(origin, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cacheDir, "last-used.json"), data, 0644)
+}
+
+// lastScaffoldOrigin returns the scaffold the last project was created from.
+func lastScaffoldOrigin() (ScaffoldOrigin, error) {
+	var origin ScaffoldOrigin
+	cacheDir := scaffoldCache()
+	if cacheDir == "" {
+		return origin, fs.ErrNotExist
+	}
+	data, err := os.ReadFile(filepath.Join(cacheDir, "last-used.json"))
+	if err != nil {
+		return origin, err
+	}
+	return origin, json.Unmarshal(data, &origin)
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEmbeddedScaffoldChecksumsUpToDate(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("_scaffold", scaffoldChecksumFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums, err := parseChecksums(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyScaffold(os.DirFS("_scaffold"), sums); err != nil {
+		t.Errorf("%v, run `lemmego scaffold checksums` to update _scaffold/%s", err, scaffoldChecksumFile)
+	}
+}
+
+func TestExtractScaffoldTarball(t *testing.T) {
+	dest := t.TempDir()
+	data := testTarball(t, map[string]string{
+		"lemmego-cli-abc123/README.md":           "readme",
+		"lemmego-cli-abc123/_scaffold/VERSION":   "1.0.0\n",
+		"lemmego-cli-abc123/_scaffold/base/a.go": "package a\n",
+	})
+	if err := extractScaffoldTarball(bytes.NewReader(data), dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "_scaffold", "base", "a.go")); err != nil {
+		t.Error("expected the scaffold files to be extracted")
+	}
+	if _, err := os.Stat(filepath.Join(dest, "README.md")); err == nil {
+		t.Error("expected files outside _scaffold to be skipped")
+	}
+
+	data = testTarball(t, map[string]string{"top/_scaffold/../../evil": "x"})
+	if err := extractScaffoldTarball(bytes.NewReader(data), t.TempDir()); err == nil {
+		t.Error("expected an error for a path leaving the destination")
+	}
+
+	data = testTarball(t, map[string]string{"top/README.md": "readme"})
+	if err := extractScaffoldTarball(bytes.NewReader(data), t.TempDir()); err == nil {
+		t.Error("expected an error for an archive without a scaffold")
+	}
+}
+
+func TestVerifyScaffold(t *testing.T) {
+	fsys := fstest.MapFS{
+		"VERSION":      {Data: []byte("1.0.0\n")},
+		"base/go.mod":  {Data: []byte("module x\n")},
+		"stubs/go.tpl": {Data: []byte("{{.ModuleName}}")},
+	}
+	sums, err := scaffoldChecksums(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseChecksums(formatChecksums(sums))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyScaffold(fsys, parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys["base/go.mod"] = &fstest.MapFile{Data: []byte("module y\n")}
+	if err := verifyScaffold(fsys, parsed); err == nil {
+		t.Error("expected an error for a modified file")
+	}
+	delete(fsys, "base/go.mod")
+	if err := verifyScaffold(fsys, parsed); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	fsys["base/go.mod"] = &fstest.MapFile{Data: []byte("module x\n")}
+	fsys["base/extra.go"] = &fstest.MapFile{Data: []byte("package extra\n")}
+	if err := verifyScaffold(fsys, parsed); err == nil {
+		t.Error("expected an error for an unlisted file")
+	}
+
+	if _, err := parseChecksums([]byte("abc  VERSION\n")); err == nil {
+		t.Error("expected an error for a malformed checksum")
+	}
+}
+
+func TestResolvePinnedScaffold(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	files := map[string]string{
+		"VERSION":             "2.0.0\n",
+		"base/go.mod":         "module x\n",
+		"stubs/versions.json": "{}\n",
+	}
+	fsys := fstest.MapFS{}
+	archive := map[string]string{}
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+		archive["lemmego-cli-abc123/_scaffold/"+name] = content
+	}
+	sums, err := scaffoldChecksums(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksums := formatChecksums(sums)
+	tarball := testTarball(t, archive)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/raw/v2.0.0/_scaffold/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(checksums)
+	})
+	mux.HandleFunc("/tarball/v2.0.0", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tarball)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	defer func(raw, tarballURL string) { scaffoldRawURL, scaffoldTarballURL = raw, tarballURL }(scaffoldRawURL, scaffoldTarballURL)
+	scaffoldRawURL, scaffoldTarballURL = srv.URL+"/raw", srv.URL+"/tarball"
+
+	_, origin, err := resolveScaffold("v2.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if origin.Source != "cache" || origin.Version != "2.0.0" {
+		t.Errorf("unexpected origin %+v", origin)
+	}
+	if refs := cachedScaffoldRefs(); len(refs) != 1 || refs[0] != "v2.0.0" {
+		t.Errorf("cachedScaffoldRefs() = %v, want [v2.0.0]", refs)
+	}
+
+	t.Setenv("LEMMEGO_OFFLINE", "1")
+	if _, _, err := resolveScaffold("v2.0.0"); err != nil {
+		t.Errorf("expected the cached scaffold to be used offline, got %v", err)
+	}
+	t.Setenv("LEMMEGO_OFFLINE", "")
+
+	// A tampered cache fails verification and is fetched again.
+	if err := os.WriteFile(filepath.Join(origin.Path, "base", "go.mod"), []byte("module evil\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cachedScaffold("v2.0.0"); err == nil {
+		t.Error("expected the tampered cache to fail verification")
+	}
+	if _, _, err := resolveScaffold("v2.0.0"); err != nil {
+		t.Errorf("expected the scaffold to be fetched again, got %v", err)
+	}
+
+	checksums = bytes.Replace(checksums, []byte(sums["VERSION"]), []byte(sums["base/go.mod"]), 1)
+	os.RemoveAll(scaffoldCacheEntry("v2.0.0"))
+	if _, _, err := resolveScaffold("v2.0.0"); err == nil {
+		t.Error("expected an error for a scaffold that does not match its checksums")
+	}
+	if dirExists(scaffoldCacheEntry("v2.0.0")) {
+		t.Error("a scaffold failing verification must not be cached")
+	}
+
+	if _, _, err := resolveScaffold("v9.9.9"); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+}
+
+func TestResolveScaffoldOffline(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("LEMMEGO_OFFLINE", "1")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request for %s while offline", r.URL)
+	}))
+	defer srv.Close()
+	defer func(raw, tarballURL string) { scaffoldRawURL, scaffoldTarballURL = raw, tarballURL }(scaffoldRawURL, scaffoldTarballURL)
+	scaffoldRawURL, scaffoldTarballURL = srv.URL+"/raw", srv.URL+"/tarball"
+
+	_, origin, err := resolveScaffold("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if origin.Source != "embedded" {
+		t.Errorf("expected the built-in scaffold offline, got %+v", origin)
+	}
+
+	if _, _, err := resolveScaffold("v2.0.0"); err == nil || !strings.Contains(err.Error(), "cache pull") {
+		t.Errorf("expected an error pointing to cache pull, got %v", err)
+	}
+	if err := fetchScaffold("v2.0.0"); err == nil {
+		t.Error("expected fetching to fail offline")
+	}
+}
@@ -0,0 +1,356 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OptionSelect  = "select"
+	OptionConfirm = "confirm"
+	OptionInput   = "input"
+)
+
+// ScaffoldManifest declares the options of a scaffold, the values they
+// accept, and the overlays and stubs each value enables. It is read from
+// manifest.yaml at the root of the scaffold.
+type ScaffoldManifest struct {
+	Options []*ManifestOption `yaml:"options"`
+	Stubs   map[string]string `yaml:"stubs"` // Destination path to stub, rendered for every project
+	Dirs    []string          `yaml:"dirs"`
+}
+
+type ManifestOption struct {
+	Name         string            `yaml:"name"`
+	Title        string            `yaml:"title"`
+	Type         string            `yaml:"type"`
+	Default      string            `yaml:"default"`
+	Experimental bool              `yaml:"experimental"`
+	Optional     bool              `yaml:"optional"` // Takes its default instead of being required without a terminal
+	When         ManifestCondition `yaml:"when"`
+	Choices      []*ManifestChoice `yaml:"choices"`
+}
+
+type ManifestChoice struct {
+	Value    string             `yaml:"value"`
+	Label    string             `yaml:"label"`
+	Overlays []*ManifestOverlay `yaml:"overlays"`
+	Stubs    map[string]string  `yaml:"stubs"`
+}
+
+// ManifestOverlay names a directory under overlays/. It is written either as
+// a plain name or as a mapping with a name and a when condition.
+type ManifestOverlay struct {
+	Name string            `yaml:"name"`
+	When ManifestCondition `yaml:"when"`
+}
+
+func (o *ManifestOverlay) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&o.Name)
+	}
+	type plain ManifestOverlay
+	return node.Decode((*plain)(o))
+}
+
+// ManifestCondition maps option names to the values they must have. A value
+// is written either as a single string or as a list of strings.
+type ManifestCondition map[string]manifestValues
+
+type manifestValues []string
+
+func (v *manifestValues) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = manifestValues{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(v))
+}
+
+// Matches reports whether values satisfy every part of the condition.
+func (c ManifestCondition) Matches(values map[string]string) bool {
+	for name, allowed := range c {
+		if !slices.Contains(allowed, values[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// undecided reports whether the condition depends on an option that has no
+// value yet.
+func (c ManifestCondition) undecided(values map[string]string) bool {
+	for name := range c {
+		if values[name] == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedBy reports whether an option the condition names already has a
+// value it doesn't allow, so that the condition can't be met whatever the
+// remaining options are set to. Options missing from decided are open.
+func (c ManifestCondition) excludedBy(decided map[string]string) bool {
+	for name, allowed := range c {
+		if value, ok := decided[name]; ok && !slices.Contains(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c ManifestCondition) String() string {
+	var parts []string
+	for _, name := range slices.Sorted(maps.Keys(c)) {
+		parts = append(parts, name+"="+strings.Join(c[name], "|"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// loadScaffoldManifest reads manifest.yaml from the scaffold source, falling
+// back to the manifest of the embedded scaffold for scaffolds without one.
+func loadScaffoldManifest(src scaffoldSource) (*ScaffoldManifest, error) {
+	data, err := fs.ReadFile(src.fs, path.Join(src.prefix, "manifest.yaml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = fs.ReadFile(scaffoldEmbedFS, "_scaffold/manifest.yaml")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseScaffoldManifest(data)
+}
+
+func parseScaffoldManifest(data []byte) (*ScaffoldManifest, error) {
+	var m ScaffoldManifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing scaffold manifest: %w", err)
+	}
+	if err := m.check(); err != nil {
+		return nil, fmt.Errorf("scaffold manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// check reports mistakes in the manifest itself. Options may only depend on
+// options declared before them, since they are asked in order.
+func (m *ScaffoldManifest) check() error {
+	seen := map[string]bool{}
+	for _, opt := range m.Options {
+		if opt.Name == "" {
+			return errors.New("an option has no name")
+		}
+		if seen[opt.Name] {
+			return fmt.Errorf("option %s is declared twice", opt.Name)
+		}
+		for name := range opt.When {
+			if !seen[name] {
+				return fmt.Errorf("option %s depends on %s, which is not declared before it", opt.Name, name)
+			}
+		}
+		seen[opt.Name] = true
+
+		switch opt.Type {
+		case "":
+			opt.Type = OptionSelect
+		case OptionSelect, OptionConfirm, OptionInput:
+		default:
+			return fmt.Errorf("option %s has an unknown type %q", opt.Name, opt.Type)
+		}
+		if opt.Type == OptionSelect && len(opt.Choices) == 0 {
+			return fmt.Errorf("option %s has no choices", opt.Name)
+		}
+		if opt.Default != "" && !opt.accepts(opt.Default) {
+			return fmt.Errorf("option %s has an invalid default %q", opt.Name, opt.Default)
+		}
+	}
+
+	for _, opt := range m.Options {
+		for _, choice := range opt.Choices {
+			for _, overlay := range choice.Overlays {
+				for name := range overlay.When {
+					if !seen[name] {
+						return fmt.Errorf("overlay %s depends on the unknown option %s", overlay.Name, name)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (m *ScaffoldManifest) Option(name string) *ManifestOption {
+	for _, opt := range m.Options {
+		if opt.Name == name {
+			return opt
+		}
+	}
+	return nil
+}
+
+// allowed returns the values the option accepts, or nil for free input.
+func (o *ManifestOption) allowed() []string {
+	switch o.Type {
+	case OptionConfirm:
+		return []string{"true", "false"}
+	case OptionInput:
+		return nil
+	}
+	var values []string
+	for _, choice := range o.Choices {
+		values = append(values, choice.Value)
+	}
+	return values
+}
+
+func (o *ManifestOption) accepts(value string) bool {
+	allowed := o.allowed()
+	return allowed == nil || slices.Contains(allowed, value)
+}
+
+func (o *ManifestOption) choice(value string) *ManifestChoice {
+	for _, choice := range o.Choices {
+		if choice.Value == value {
+			return choice
+		}
+	}
+	return nil
+}
+
+// asked reports whether the option is put to the user at all. Experimental
+// options are only asked with --exp.
+func (o *ManifestOption) asked(enableExperimental bool) bool {
+	return !o.Experimental || enableExperimental
+}
+
+// CheckPartial reports invalid values among those supplied up front, leaving
+// missing ones alone.
+func (m *ScaffoldManifest) CheckPartial(values map[string]string, enableExperimental bool) error {
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if m.Option(name) == nil {
+			return fmt.Errorf("unknown option %q", name)
+		}
+	}
+
+	for _, opt := range m.Options {
+		value, ok := values[opt.Name]
+		if !ok || value == "" {
+			continue
+		}
+		if !opt.accepts(value) {
+			return invalidChoiceError(opt.Name, value, opt.allowed())
+		}
+		if !opt.When.undecided(values) && !opt.When.Matches(values) {
+			return fmt.Errorf("--%s cannot be used unless %s", opt.Name, opt.When)
+		}
+		if !opt.asked(enableExperimental) && value != opt.Default {
+			return fmt.Errorf("%s is experimental, pass --exp to enable it", opt.Name)
+		}
+	}
+	return nil
+}
+
+// Missing returns the names of the options that still have to be asked for,
+// given the values supplied so far. Options whose condition cannot be decided
+// yet count as missing, optional ones never do.
+func (m *ScaffoldManifest) Missing(values map[string]string, enableExperimental bool) []string {
+	var missing []string
+	for _, opt := range m.Options {
+		if values[opt.Name] != "" || !opt.asked(enableExperimental) || opt.Optional {
+			continue
+		}
+		if opt.When.undecided(values) || opt.When.Matches(values) {
+			missing = append(missing, opt.Name)
+		}
+	}
+	return missing
+}
+
+// Check reports invalid, missing or conflicting values in a complete set of
+// values. Optional options may be left empty.
+func (m *ScaffoldManifest) Check(values map[string]string) error {
+	for _, opt := range m.Options {
+		value := values[opt.Name]
+		if !opt.When.Matches(values) {
+			if value != "" {
+				return fmt.Errorf("%s %q cannot be used unless %s", opt.Name, value, opt.When)
+			}
+			continue
+		}
+		if value == "" && opt.Optional {
+			continue
+		}
+		if value == "" {
+			return fmt.Errorf("%s is required", opt.Name)
+		}
+		if !opt.accepts(value) {
+			return invalidChoiceError(opt.Name, value, opt.allowed())
+		}
+	}
+	return nil
+}
+
+// Complete fills in the defaults of the options that are not asked or
+// optional and clears the options whose condition is not met.
+func (m *ScaffoldManifest) Complete(values map[string]string, enableExperimental bool) map[string]string {
+	completed := maps.Clone(values)
+	for _, opt := range m.Options {
+		switch {
+		case !opt.When.Matches(completed):
+			delete(completed, opt.Name)
+		case completed[opt.Name] == "" && opt.Default != "" && (opt.Optional || !opt.asked(enableExperimental)):
+			completed[opt.Name] = opt.Default
+		}
+	}
+	return completed
+}
+
+// withOptionalDefaults returns values with the default of every optional
+// option that has no value, so that configs recorded before the option
+// existed render what they always did until it is turned off.
+func (m *ScaffoldManifest) withOptionalDefaults(values map[string]string) map[string]string {
+	filled := maps.Clone(values)
+	if filled == nil {
+		filled = map[string]string{}
+	}
+	for _, opt := range m.Options {
+		if opt.Optional && filled[opt.Name] == "" && opt.When.Matches(filled) {
+			filled[opt.Name] = opt.Default
+		}
+	}
+	return filled
+}
+
+// Resolve returns the overlays, in order, and the stubs that the values
+// enable.
+func (m *ScaffoldManifest) Resolve(values map[string]string) ([]string, map[string]string) {
+	var overlays []string
+	stubs := maps.Clone(m.Stubs)
+	if stubs == nil {
+		stubs = map[string]string{}
+	}
+
+	for _, opt := range m.Options {
+		if !opt.When.Matches(values) {
+			continue
+		}
+		choice := opt.choice(values[opt.Name])
+		if choice == nil {
+			continue
+		}
+		for _, overlay := range choice.Overlays {
+			if overlay.When.Matches(values) {
+				overlays = append(overlays, path.Join("overlays", overlay.Name))
+			}
+		}
+		maps.Copy(stubs, choice.Stubs)
+	}
+	return overlays, stubs
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"slices"
+	"testing"
+)
+
+func testManifest(t *testing.T) *ScaffoldManifest {
+	t.Helper()
+	m, err := loadScaffoldManifest(scaffoldSource{fs: scaffoldEmbedFS, prefix: "_scaffold"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestManifestResolve(t *testing.T) {
+	m := testManifest(t)
+
+	cfg := ProjectConfig{
+		Preset:     PresetMVC,
+		ORM:        OrmBun,
+		Frontend:   FrontendTemplInertiaVue,
+		EnableAuth: true,
+	}
+	overlays, stubs := m.Resolve(cfg.values())
+
+	want := []string{"overlays/mvc", "overlays/frontend_templ_inertia_vue", "overlays/auth_bun"}
+	if !slices.Equal(overlays, want) {
+		t.Errorf("overlays = %v, want %v", overlays, want)
+	}
+	for _, dest := range []string{"go.mod", "internal/routes/web.go", "internal/routes/api.go", "package.json", "vite.config.js"} {
+		if _, ok := stubs[dest]; !ok {
+			t.Errorf("expected a stub for %s, got %v", dest, stubs)
+		}
+	}
+
+	cfg = ProjectConfig{Preset: PresetRESTAPI, ORM: OrmGORM, Frontend: FrontendInertiaReact}
+	overlays, stubs = m.Resolve(cfg.values())
+	if !slices.Equal(overlays, []string{"overlays/rest_api"}) {
+		t.Errorf("overlays = %v, want only rest_api", overlays)
+	}
+	if _, ok := stubs["package.json"]; ok {
+		t.Error("a frontend must not apply to the rest_api preset")
+	}
+	if _, ok := stubs["internal/routes/web.go"]; ok {
+		t.Error("web routes must not be rendered for the rest_api preset")
+	}
+}
+
+func TestManifestCustomOption(t *testing.T) {
+	m, err := parseScaffoldManifest([]byte(`
+options:
+  - name: module
+    type: input
+  - name: queue
+    title: Queue driver
+    choices:
+      - value: none
+      - value: redis
+        overlays: [queue_redis]
+        stubs: {internal/configs/queue.go: queue.go.tpl}
+  - name: dashboard
+    type: confirm
+    when:
+      queue: [redis]
+    choices:
+      - value: "true"
+        overlays:
+          - name: queue_dashboard
+stubs:
+  go.mod: go.mod.tpl
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ProjectOptions{ModuleName: "m", Options: map[string]string{"queue": "redis"}}
+	if err := opts.Validate(m, false); err != nil {
+		t.Fatal(err)
+	}
+	if missing := opts.Missing(m, false); !slices.Equal(missing, []string{"dashboard"}) {
+		t.Errorf("missing = %v, want [dashboard]", missing)
+	}
+
+	cfg := newProjectConfig("app", map[string]string{"module": "m", "queue": "redis", "dashboard": "true"})
+	if err := m.Check(cfg.Options); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Option("queue") != "redis" {
+		t.Errorf("Option(queue) = %q, want redis", cfg.Option("queue"))
+	}
+	overlays, stubs := m.Resolve(cfg.Options)
+	if !slices.Equal(overlays, []string{"overlays/queue_redis", "overlays/queue_dashboard"}) {
+		t.Errorf("unexpected overlays %v", overlays)
+	}
+	if stubs["internal/configs/queue.go"] != "queue.go.tpl" || stubs["go.mod"] != "go.mod.tpl" {
+		t.Errorf("unexpected stubs %v", stubs)
+	}
+
+	if err := m.Check(map[string]string{"module": "m", "queue": "none", "dashboard": "true"}); err == nil {
+		t.Error("expected an error for an option whose condition is not met")
+	}
+	if err := (ProjectOptions{Options: map[string]string{"cache": "on"}}).Validate(m, false); err == nil {
+		t.Error("expected an error for an unknown option")
+	}
+}
+
+func TestManifestComplete(t *testing.T) {
+	m := testManifest(t)
+	values := m.Complete(map[string]string{"preset": "rest_api", "frontend": "templ"}, false)
+	if values["gpa"] != "false" {
+		t.Errorf("expected the experimental gpa option to default to false, got %q", values["gpa"])
+	}
+	if _, ok := values["frontend"]; ok {
+		t.Error("expected frontend to be cleared for the rest_api preset")
+	}
+	if values["queue"] != "true" {
+		t.Errorf("expected the optional queue option to default to true, got %q", values["queue"])
+	}
+	if values := m.Complete(map[string]string{"preset": "rest_api", "queue": "false"}, false); values["queue"] != "false" {
+		t.Errorf("expected a turned off queue to stay off, got %q", values["queue"])
+	}
+}
+
+func TestParseScaffoldManifestErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown type":      "options: [{name: a, type: radio}]",
+		"no choices":        "options: [{name: a}]",
+		"duplicate":         "options: [{name: a, type: input}, {name: a, type: input}]",
+		"forward reference": "options: [{name: a, type: input, when: {b: x}}, {name: b, type: input}]",
+		"bad default":       "options: [{name: a, type: confirm, default: maybe}]",
+	}
+	for name, data := range tests {
+		if _, err := parseScaffoldManifest([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
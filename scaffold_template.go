@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// gitTemplatePrefix marks a --template value as a git repository URL, e.g.
+// git+https://github.com/acme/starter.git#v2 or git+file:///srv/starter.
+const gitTemplatePrefix = "git+"
+
+// openScaffoldTemplate resolves the value of --template to a scaffold source.
+// A template is a directory, or a git repository, laid out like the built-in
+// scaffold: base/, overlays/ and stubs/ with an optional stubs/versions.json,
+// either at its root or under _scaffold/. The returned function removes
+// anything that was fetched for the template and must be called when done.
+func openScaffoldTemplate(template string) (scaffoldSource, func(), error) {
+	cleanup := func() {}
+
+	dir := template
+	if repo, ok := strings.CutPrefix(template, gitTemplatePrefix); ok {
+		if repoURL, _, _ := strings.Cut(repo, "#"); isOffline() && !isLocalRepo(repoURL) {
+			return scaffoldSource{}, cleanup, fmt.Errorf("cannot clone template %s while offline, clone it yourself and pass its directory to --template", repoURL)
+		}
+		cloned, err := cloneTemplate(repo)
+		if err != nil {
+			return scaffoldSource{}, cleanup, err
+		}
+		cleanup = func() { os.RemoveAll(cloned) }
+		dir = cloned
+	}
+
+	src, err := templateScaffoldSource(dir)
+	if err != nil {
+		cleanup()
+		return scaffoldSource{}, func() {}, fmt.Errorf("template %s: %w", template, err)
+	}
+	return src, cleanup, nil
+}
+
+// templateScaffoldSource returns the scaffold in dir after checking that it
+// has the directories every scaffold needs.
+func templateScaffoldSource(dir string) (scaffoldSource, error) {
+	if !dirExists(dir) {
+		return scaffoldSource{}, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	src := scaffoldSource{fs: os.DirFS(dir), prefix: ""}
+	if dirExists(filepath.Join(dir, "_scaffold")) {
+		src.prefix = "_scaffold"
+	}
+
+	for _, required := range []string{"base", "stubs"} {
+		info, err := fs.Stat(src.fs, path.Join(src.prefix, required))
+		if err != nil || !info.IsDir() {
+			return scaffoldSource{}, fmt.Errorf("missing the %s/ directory of a scaffold", required)
+		}
+	}
+	return src, nil
+}
+
+// isLocalRepo reports whether git can clone repoURL without the network:
+// file:// URLs and paths, as opposed to other URLs and scp-like
+// user@host:path addresses.
+func isLocalRepo(repoURL string) bool {
+	if strings.HasPrefix(repoURL, "file://") {
+		return true
+	}
+	if strings.Contains(repoURL, "://") {
+		return false
+	}
+	// A colon before the first slash makes an scp-like address, except for a
+	// Windows drive letter.
+	before, _, _ := strings.Cut(filepath.ToSlash(repoURL), "/")
+	return !strings.Contains(before, ":") || filepath.VolumeName(repoURL) != ""
+}
+
+// cloneTemplate makes a shallow clone of repo into a temporary directory and
+// returns its path. A #ref suffix selects a branch or tag.
+func cloneTemplate(repo string) (string, error) {
+	if !HasBinary("git") {
+		return "", fmt.Errorf("git must be installed to use a git template")
+	}
+
+	repoURL, ref, _ := strings.Cut(repo, "#")
+	dir, err := os.MkdirTemp("", "lemmego-template-*")
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, repoURL, dir)
+
+	fmt.Printf("> Cloning template %s...\n", repo)
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("cloning %s: %w\n%s", repoURL, err, output)
+	}
+	return dir, nil
+}
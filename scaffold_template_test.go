@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bytes"
+	"go/format"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestTemplate copies the built-in scaffold into dir, adds a marker file
+// to base/ and pins github.com/lemmego/api to a custom version.
+func writeTestTemplate(t *testing.T, dir string) {
+	t.Helper()
+	scaffold, err := fs.Sub(scaffoldEmbedFS, "_scaffold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.CopyFS(dir, scaffold); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "base", "LOGGING.md"), []byte("acme logging\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	versions := `{"github.com/lemmego/api": "v9.9.9"}`
+	if err := os.WriteFile(filepath.Join(dir, "stubs", "versions.json"), []byte(versions), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func scaffoldFromTemplate(t *testing.T, template string) string {
+	t.Helper()
+	src, cleanup, err := openScaffoldTemplate(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	dest := t.TempDir()
+	cfg := ProjectConfig{
+		Name:       "testproj",
+		ModuleName: "github.com/test/testproj",
+		Preset:     PresetRESTAPI,
+		ORM:        OrmGORM,
+	}
+	if err := scaffoldProjectFrom(src, ScaffoldOrigin{Source: "template", Path: template}, cfg, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dest, "LOGGING.md")); err != nil {
+		t.Error("expected the template's base files to be copied")
+	}
+	goMod, err := os.ReadFile(filepath.Join(dest, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(goMod), "github.com/lemmego/api v9.9.9") {
+		t.Errorf("expected the template's versions.json to be used, got:\n%s", goMod)
+	}
+	return dest
+}
+
+func TestScaffoldFromLocalTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTestTemplate(t, dir)
+	scaffoldFromTemplate(t, dir)
+}
+
+func TestScaffoldFromTemplateInScaffoldDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTestTemplate(t, filepath.Join(dir, "_scaffold"))
+	scaffoldFromTemplate(t, dir)
+}
+
+func TestScaffoldFromGitTemplate(t *testing.T) {
+	if !HasBinary("git") {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	writeTestTemplate(t, dir)
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "starter"},
+		{"tag", "v1"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	scaffoldFromTemplate(t, "git+file://"+filepath.ToSlash(dir)+"#v1")
+}
+
+func TestOpenInvalidTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "base"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := openScaffoldTemplate(dir); err == nil || !strings.Contains(err.Error(), "stubs/") {
+		t.Errorf("expected a missing stubs/ error, got %v", err)
+	}
+	if _, _, err := openScaffoldTemplate(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestOpenGitTemplateOffline(t *testing.T) {
+	t.Setenv("LEMMEGO_OFFLINE", "1")
+
+	_, _, err := openScaffoldTemplate("git+https://github.com/acme/starter.git#v2")
+	if err == nil || !strings.Contains(err.Error(), "cannot clone template https://github.com/acme/starter.git while offline") {
+		t.Errorf("expected cloning over the network to be refused offline, got %v", err)
+	}
+
+	for repo, local := range map[string]bool{
+		"file:///srv/starter":                 true,
+		"/srv/starter":                        true,
+		"../starter":                          true,
+		"git@github.com:acme/starter.git":     false,
+		"ssh://git@github.com/acme/starter":   false,
+		"https://github.com/acme/starter.git": false,
+	} {
+		if got := isLocalRepo(repo); got != local {
+			t.Errorf("isLocalRepo(%q) = %v, want %v", repo, got, local)
+		}
+	}
+}
+
+func TestScaffoldFormatsGoStubs(t *testing.T) {
+	src, origin := embeddedScaffold()
+	m, err := loadScaffoldManifest(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := ProjectConfig{Name: "app", ModuleName: "github.com/acme/app", Preset: PresetMVC, ORM: OrmBun, Frontend: FrontendTemplInertiaReact, EnableAuth: true}
+	dest := t.TempDir()
+	if err := renderScaffold(src, origin, cfg, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stubs := m.Resolve(m.withOptionalDefaults(cfg.values()))
+	for file := range stubs {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dest, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if formatted, err := format.Source(data); err != nil || !bytes.Equal(formatted, data) {
+			t.Errorf("expected %s to be gofmt'd, got:\n%s", file, data)
+		}
+	}
+}
+
+func TestScaffoldReportsInvalidGoStub(t *testing.T) {
+	dir := t.TempDir()
+	writeTestTemplate(t, dir)
+	stub := filepath.Join(dir, "stubs", "main.go.tpl")
+	data, err := os.ReadFile(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stub, append(data, "\nfunc broken( {\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, cleanup, err := openScaffoldTemplate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	cfg := ProjectConfig{Name: "app", ModuleName: "github.com/acme/app", Preset: PresetRESTAPI, ORM: OrmGORM}
+	err = renderScaffold(src, ScaffoldOrigin{Source: "template", Path: dir}, cfg, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "stub main.go.tpl renders invalid Go for cmd/app/main.go: line ") {
+		t.Errorf("expected the broken stub and line to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,440 @@
+package cli
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// DBTable is a table read from an existing database.
+type DBTable struct {
+	Name        string
+	Columns     []*DBColumn
+	Indexes     []*DBIndex // Indexes and unique keys other than the primary key
+	ForeignKeys []*DBForeignKey
+}
+
+// DBColumn is a column of a DBTable. Type is the database type in lower
+// case, e.g. varchar(255) or bigint unsigned.
+type DBColumn struct {
+	Name          string
+	Type          string
+	Length        int // Maximum length of char and varchar columns; 0 when unbounded
+	Nullable      bool
+	Primary       bool
+	AutoIncrement bool
+}
+
+type DBIndex struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+type DBForeignKey struct {
+	Column    string
+	RefTable  string
+	RefColumn string
+	OnDelete  string // Referential action in upper case; empty for NO ACTION
+}
+
+// schemaDialect reads the structure of a database of one kind.
+type schemaDialect interface {
+	tables(db *sql.DB) ([]string, error)
+	columns(db *sql.DB, table string) ([]*DBColumn, error)
+	indexes(db *sql.DB, table string) ([]*DBIndex, error)
+	foreignKeys(db *sql.DB, table string) ([]*DBForeignKey, error)
+}
+
+// ignoredTables are bookkeeping tables that no model or migration is
+// generated for.
+var ignoredTables = []string{"schema_migrations"}
+
+// openProjectDB connects to the database configured by the DB_* settings in
+// the project's .env file, falling back to the environment and to the
+// defaults of the database config stub.
+func openProjectDB() (*sql.DB, schemaDialect, error) {
+	env, err := godotenv.Read(".env")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("reading .env: %w", err)
+	}
+	setting := func(key, fallback string) string {
+		if v, ok := env[key]; ok && v != "" {
+			return v
+		}
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		return fallback
+	}
+
+	switch connection := setting("DB_CONNECTION", "sqlite"); connection {
+	case "sqlite":
+		path := setting("DB_DATABASE", "./storage/database.sqlite")
+		if !fileExists(path) {
+			return nil, nil, fmt.Errorf("sqlite database %s does not exist", path)
+		}
+		db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+		return db, sqliteDialect{}, err
+	case "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s",
+			setting("DB_USERNAME", "root"),
+			setting("DB_PASSWORD", ""),
+			setting("DB_HOST", "localhost"),
+			setting("DB_PORT", "3306"),
+			setting("DB_DATABASE", "lemmego"),
+		)
+		db, err := sql.Open("mysql", dsn)
+		return db, mysqlDialect{}, err
+	case "pgsql":
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(setting("DB_USERNAME", ""), setting("DB_PASSWORD", "")),
+			Host:     setting("DB_HOST", "localhost") + ":" + setting("DB_PORT", "5432"),
+			Path:     setting("DB_DATABASE", "lemmego"),
+			RawQuery: "sslmode=" + setting("DB_SSLMODE", "disable"),
+		}
+		db, err := sql.Open("postgres", dsn.String())
+		return db, postgresDialect{}, err
+	default:
+		return nil, nil, fmt.Errorf("unsupported DB_CONNECTION %q (expected sqlite, mysql or pgsql)", connection)
+	}
+}
+
+// inspectSchema reads the named tables, or every table when names is empty.
+func inspectSchema(db *sql.DB, dialect schemaDialect, names []string) ([]*DBTable, error) {
+	all, err := dialect.tables(db)
+	if err != nil {
+		return nil, fmt.Errorf("listing tables: %w", err)
+	}
+	for _, name := range names {
+		if !slices.Contains(all, name) {
+			return nil, fmt.Errorf("table %s does not exist", name)
+		}
+	}
+	if len(names) == 0 {
+		names = all
+	}
+
+	var tables []*DBTable
+	for _, name := range names {
+		if slices.Contains(ignoredTables, name) {
+			continue
+		}
+
+		table := &DBTable{Name: name}
+		if table.Columns, err = dialect.columns(db, name); err != nil {
+			return nil, fmt.Errorf("reading columns of %s: %w", name, err)
+		}
+		if table.Indexes, err = dialect.indexes(db, name); err != nil {
+			return nil, fmt.Errorf("reading indexes of %s: %w", name, err)
+		}
+		if table.ForeignKeys, err = dialect.foreignKeys(db, name); err != nil {
+			return nil, fmt.Errorf("reading foreign keys of %s: %w", name, err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// queryStrings runs a query returning a single column of strings.
+func queryStrings(db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// groupIndexes collects rows of (index, unique, column), ordered by index
+// and column position, into indexes.
+func groupIndexes(rows *sql.Rows) ([]*DBIndex, error) {
+	defer rows.Close()
+
+	var indexes []*DBIndex
+	for rows.Next() {
+		var name, column string
+		var unique bool
+		if err := rows.Scan(&name, &unique, &column); err != nil {
+			return nil, err
+		}
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			indexes = append(indexes, &DBIndex{Name: name, Unique: unique})
+		}
+		last := indexes[len(indexes)-1]
+		last.Columns = append(last.Columns, column)
+	}
+	return indexes, rows.Err()
+}
+
+// charLength returns the length declared by a char or varchar type such as
+// varchar(191), or 0 for other types and types without one.
+func charLength(columnType string) int {
+	base, args, ok := strings.Cut(columnType, "(")
+	if !ok || !strings.Contains(base, "char") {
+		return 0
+	}
+	args, _, _ = strings.Cut(args, ")")
+	length, _ := strconv.Atoi(strings.TrimSpace(args))
+	return length
+}
+
+// referentialAction normalizes an ON DELETE rule, dropping the default.
+func referentialAction(rule string) string {
+	rule = strings.ToUpper(rule)
+	if rule == "NO ACTION" {
+		return ""
+	}
+	return rule
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) tables(db *sql.DB) ([]string, error) {
+	return queryStrings(db, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+}
+
+func (sqliteDialect) columns(db *sql.DB, table string) ([]*DBColumn, error) {
+	rows, err := db.Query(`SELECT name, type, "notnull", pk FROM pragma_table_info(?) ORDER BY cid`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []*DBColumn
+	primaries := 0
+	for rows.Next() {
+		var c DBColumn
+		var notNull bool
+		var pk int
+		if err := rows.Scan(&c.Name, &c.Type, &notNull, &pk); err != nil {
+			return nil, err
+		}
+		c.Type = strings.ToLower(c.Type)
+		c.Length = charLength(c.Type)
+		c.Nullable = !notNull && pk == 0
+		c.Primary = pk > 0
+		if c.Primary {
+			primaries++
+		}
+		columns = append(columns, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range columns {
+		if c.Type != "integer" {
+			continue
+		}
+		// A single INTEGER primary key is an alias of the rowid, which SQLite
+		// assigns automatically.
+		c.AutoIncrement = c.Primary && primaries == 1
+		// SQLite integers are 64 bits wide.
+		c.Type = "bigint"
+	}
+	return columns, nil
+}
+
+func (sqliteDialect) indexes(db *sql.DB, table string) ([]*DBIndex, error) {
+	rows, err := db.Query(`SELECT il.name, il."unique", ii.name
+		FROM pragma_index_list(?) AS il, pragma_index_info(il.name) AS ii
+		WHERE il.origin != 'pk'
+		ORDER BY il.name, ii.seqno`, table)
+	if err != nil {
+		return nil, err
+	}
+	return groupIndexes(rows)
+}
+
+func (sqliteDialect) foreignKeys(db *sql.DB, table string) ([]*DBForeignKey, error) {
+	rows, err := db.Query(`SELECT "from", "table", "to", on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*DBForeignKey
+	for rows.Next() {
+		var fk DBForeignKey
+		var refColumn sql.NullString
+		if err := rows.Scan(&fk.Column, &fk.RefTable, &refColumn, &fk.OnDelete); err != nil {
+			return nil, err
+		}
+		// SQLite leaves the column empty when the key references the primary key.
+		fk.RefColumn = refColumn.String
+		if fk.RefColumn == "" {
+			fk.RefColumn = "id"
+		}
+		fk.OnDelete = referentialAction(fk.OnDelete)
+		keys = append(keys, &fk)
+	}
+	return keys, rows.Err()
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) tables(db *sql.DB) ([]string, error) {
+	return queryStrings(db, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name`)
+}
+
+func (mysqlDialect) columns(db *sql.DB, table string) ([]*DBColumn, error) {
+	rows, err := db.Query(`SELECT column_name, column_type, is_nullable, column_key, extra
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ?
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []*DBColumn
+	for rows.Next() {
+		var name, columnType, nullable, key, extra string
+		if err := rows.Scan(&name, &columnType, &nullable, &key, &extra); err != nil {
+			return nil, err
+		}
+		columns = append(columns, &DBColumn{
+			Name:          name,
+			Type:          strings.ToLower(columnType),
+			Length:        charLength(strings.ToLower(columnType)),
+			Nullable:      nullable == "YES",
+			Primary:       key == "PRI",
+			AutoIncrement: strings.Contains(extra, "auto_increment"),
+		})
+	}
+	return columns, rows.Err()
+}
+
+func (mysqlDialect) indexes(db *sql.DB, table string) ([]*DBIndex, error) {
+	rows, err := db.Query(`SELECT index_name, non_unique = 0, column_name
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ? AND index_name != 'PRIMARY'
+		ORDER BY index_name, seq_in_index`, table)
+	if err != nil {
+		return nil, err
+	}
+	return groupIndexes(rows)
+}
+
+func (mysqlDialect) foreignKeys(db *sql.DB, table string) ([]*DBForeignKey, error) {
+	rows, err := db.Query(`SELECT k.column_name, k.referenced_table_name, k.referenced_column_name, r.delete_rule
+		FROM information_schema.key_column_usage k
+		JOIN information_schema.referential_constraints r
+			ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name
+		WHERE k.table_schema = DATABASE() AND k.table_name = ? AND k.referenced_table_name IS NOT NULL
+		ORDER BY k.constraint_name, k.ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	return scanForeignKeys(rows)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) tables(db *sql.DB) ([]string, error) {
+	return queryStrings(db, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name`)
+}
+
+func (postgresDialect) columns(db *sql.DB, table string) ([]*DBColumn, error) {
+	rows, err := db.Query(`SELECT c.column_name, c.data_type, COALESCE(c.character_maximum_length, 0), c.is_nullable, COALESCE(c.column_default, ''), c.is_identity,
+			EXISTS (
+				SELECT 1 FROM information_schema.table_constraints tc
+				JOIN information_schema.key_column_usage k
+					ON k.constraint_name = tc.constraint_name AND k.table_schema = tc.table_schema
+				WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema
+					AND tc.table_name = c.table_name AND k.column_name = c.column_name
+			)
+		FROM information_schema.columns c
+		WHERE c.table_schema = current_schema() AND c.table_name = $1
+		ORDER BY c.ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []*DBColumn
+	for rows.Next() {
+		var c DBColumn
+		var nullable, columnDefault, identity string
+		if err := rows.Scan(&c.Name, &c.Type, &c.Length, &nullable, &columnDefault, &identity, &c.Primary); err != nil {
+			return nil, err
+		}
+		c.Type = strings.ToLower(c.Type)
+		c.Nullable = nullable == "YES"
+		c.AutoIncrement = identity == "YES" || strings.HasPrefix(columnDefault, "nextval(")
+		columns = append(columns, &c)
+	}
+	return columns, rows.Err()
+}
+
+func (postgresDialect) indexes(db *sql.DB, table string) ([]*DBIndex, error) {
+	rows, err := db.Query(`SELECT i.relname, ix.indisunique, a.attname
+		FROM pg_class t
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_index ix ON ix.indrelid = t.oid
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+		WHERE n.nspname = current_schema() AND t.relname = $1 AND NOT ix.indisprimary
+		ORDER BY i.relname, array_position(ix.indkey::int2[], a.attnum)`, table)
+	if err != nil {
+		return nil, err
+	}
+	return groupIndexes(rows)
+}
+
+func (postgresDialect) foreignKeys(db *sql.DB, table string) ([]*DBForeignKey, error) {
+	rows, err := db.Query(`SELECT k.column_name, c.table_name, c.column_name, r.delete_rule
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage k
+			ON k.constraint_name = tc.constraint_name AND k.table_schema = tc.table_schema
+		JOIN information_schema.constraint_column_usage c
+			ON c.constraint_name = tc.constraint_name AND c.table_schema = tc.table_schema
+		JOIN information_schema.referential_constraints r
+			ON r.constraint_name = tc.constraint_name AND r.constraint_schema = tc.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema() AND tc.table_name = $1
+		ORDER BY tc.constraint_name, k.ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	return scanForeignKeys(rows)
+}
+
+// scanForeignKeys reads rows of (column, referenced table, referenced column,
+// delete rule).
+func scanForeignKeys(rows *sql.Rows) ([]*DBForeignKey, error) {
+	defer rows.Close()
+
+	var keys []*DBForeignKey
+	for rows.Next() {
+		var fk DBForeignKey
+		if err := rows.Scan(&fk.Column, &fk.RefTable, &fk.RefColumn, &fk.OnDelete); err != nil {
+			return nil, err
+		}
+		fk.OnDelete = referentialAction(fk.OnDelete)
+		keys = append(keys, &fk)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// projectStubsDir is where published stubs live inside a project.
+const projectStubsDir = "stubs"
+
+// generatorStubs maps the file name of every embedded generator stub to its
+// contents.
+var generatorStubs = map[string]string{
+	"model.txt":         modelStub,
+	"migration.txt":     migrationStub,
+	"handler.txt":       handlerStub,
+	"handler_crud.txt":  handlerCrudStub,
+	"handler_plain.txt": handlerPlainStub,
+	"repo.txt":          repoStub,
+	"input.txt":         inputStub,
+	"templ_form.txt":    templFormStub,
+	"react_form.txt":    reactFormStub,
+	"vue_form.txt":      vueFormStub,
+	"gohtml_form.txt":   gohtmlFormStub,
+	"templ_index.txt":   templIndexStub,
+	"templ_show.txt":    templShowStub,
+	"gohtml_index.txt":  gohtmlIndexStub,
+	"gohtml_show.txt":   gohtmlShowStub,
+	"react_index.txt":   reactIndexStub,
+	"react_show.txt":    reactShowStub,
+	"vue_index.txt":     vueIndexStub,
+	"vue_show.txt":      vueShowStub,
+}
+
+// loadStub returns the project's published copy of the named stub if there is
+// one, and the embedded stub otherwise.
+func loadStub(name string) string {
+	if data, err := os.ReadFile(filepath.Join(projectStubsDir, name)); err == nil {
+		return string(data)
+	}
+	return generatorStubs[name]
+}
+
+// publishStubs copies the named embedded stubs, or all of them when names is
+// empty, into the project's stubs directory and returns the paths written.
+// Existing files are left alone unless force is set.
+func publishStubs(names []string, force bool) ([]string, error) {
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(generatorStubs))
+	}
+
+	if err := os.MkdirAll(projectStubsDir, 0755); err != nil {
+		return nil, err
+	}
+
+	var published []string
+	for _, name := range names {
+		content, ok := generatorStubs[name]
+		if !ok {
+			return published, fmt.Errorf("unknown stub %q", name)
+		}
+
+		dest := filepath.Join(projectStubsDir, name)
+		if _, err := os.Stat(dest); err == nil && !force {
+			fmt.Printf("Skipping %s, it already exists (use --force to overwrite it)\n", dest)
+			continue
+		}
+
+		if err := os.WriteFile(dest, []byte(content), 0644); err != nil {
+			return published, err
+		}
+		published = append(published, dest)
+	}
+	return published, nil
+}
+
+var forcePublishStubs bool
+
+func init() {
+	stubPublishCmd.Flags().BoolVar(&forcePublishStubs, "force", false, "Overwrite stubs that were already published")
+	stubCmd.AddCommand(stubPublishCmd)
+}
+
+var stubCmd = &cobra.Command{
+	Use:   "stub",
+	Short: "Manage generator stubs",
+	Long:  `Manage the stubs the generators render their output from`,
+}
+
+var stubPublishCmd = &cobra.Command{
+	Use:   "publish [stub...]",
+	Short: "Copy the generator stubs into the project",
+	Long: `Copy the generator stubs into the project's stubs directory so they can be customized.
+The generators use the published copies instead of the built-in stubs from then on.
+
+Available stubs: ` + strings.Join(slices.Sorted(maps.Keys(generatorStubs)), ", "),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isLemmegoProject() {
+			fmt.Println("Error: This does not appear to be a Lemmego project directory.")
+			return
+		}
+
+		published, err := publishStubs(args, forcePublishStubs)
+		for _, p := range published {
+			fmt.Println("Published", p)
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+	},
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPublishStubs(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	published, err := publishStubs(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != len(generatorStubs) {
+		t.Errorf("expected %d stubs to be published, got %d", len(generatorStubs), len(published))
+	}
+
+	custom := "package {{.PackageName}}\n\n// custom\ntype {{.ModelName | toCamel}} struct{}\n"
+	if err := os.WriteFile(filepath.Join(projectStubsDir, "model.txt"), []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	published, err = publishStubs([]string{"model.txt"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 0 {
+		t.Error("expected an existing stub not to be overwritten without force")
+	}
+
+	mg := NewModelGenerator(&ModelConfig{Name: "blog_post"})
+	if mg.GetStub() != custom {
+		t.Error("expected the published stub to take precedence")
+	}
+	out, err := ParseTemplate(map[string]interface{}{"PackageName": "models", "ModelName": "blog_post"}, mg.GetStub(), CommonFuncs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "type BlogPost struct{}") {
+		t.Errorf("expected CommonFuncs to be available to published stubs, got:\n%s", out)
+	}
+
+	if _, err := publishStubs([]string{"nope.txt"}, false); err == nil {
+		t.Error("expected an error for an unknown stub")
+	}
+}
+
+func TestLoadStubFallsBackToEmbedded(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if loadStub("handler.txt") != handlerStub {
+		t.Error("expected the embedded stub without a published copy")
+	}
+}
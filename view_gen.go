@@ -0,0 +1,153 @@
+package cli
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
+)
+
+//go:embed templ_index.txt
+var templIndexStub string
+
+//go:embed templ_show.txt
+var templShowStub string
+
+//go:embed gohtml_index.txt
+var gohtmlIndexStub string
+
+//go:embed gohtml_show.txt
+var gohtmlShowStub string
+
+//go:embed react_index.txt
+var reactIndexStub string
+
+//go:embed react_show.txt
+var reactShowStub string
+
+//go:embed vue_index.txt
+var vueIndexStub string
+
+//go:embed vue_show.txt
+var vueShowStub string
+
+// ResourceViews holds the list, detail and form view of a model: templ
+// components, gohtml pages or Inertia pages depending on the flavor.
+type ResourceViews struct {
+	Index string
+	Show  string
+	Form  string
+}
+
+// resourceViews names the views of model in flavor. The handlers render them
+// by these names and the view and form generators write them under them.
+func resourceViews(flavor, model string) ResourceViews {
+	switch flavor {
+	case "templ":
+		name := strcase.ToCamel(model)
+		return ResourceViews{Index: name + "Index", Show: name + "Show", Form: name}
+	case "react", "vue":
+		dir := strcase.ToCamel(pluralize.NewClient().Plural(model))
+		return ResourceViews{Index: dir + "/Index", Show: dir + "/Show", Form: "Forms/" + strcase.ToCamel(model)}
+	}
+	return ResourceViews{Index: model + "_index.page.gohtml", Show: model + "_show.page.gohtml", Form: model + ".page.gohtml"}
+}
+
+// resourceViewFiles returns the files the views of model in flavor are
+// written to.
+func resourceViewFiles(flavor, model string) ResourceViews {
+	views := resourceViews(flavor, model)
+	switch flavor {
+	case "templ":
+		return ResourceViews{
+			Index: filepath.Join("templates", model+"_index.templ"),
+			Show:  filepath.Join("templates", model+"_show.templ"),
+			Form:  filepath.Join("templates", model+".templ"),
+		}
+	case "react", "vue":
+		ext := map[string]string{"react": ".tsx", "vue": ".vue"}[flavor]
+		dir := filepath.Join("resources", "js", "Pages")
+		return ResourceViews{
+			Index: filepath.Join(dir, filepath.FromSlash(views.Index)+ext),
+			Show:  filepath.Join(dir, filepath.FromSlash(views.Show)+ext),
+			Form:  filepath.Join(dir, filepath.FromSlash(views.Form)+ext),
+		}
+	}
+	return ResourceViews{
+		Index: filepath.Join("templates", views.Index),
+		Show:  filepath.Join("templates", views.Show),
+		Form:  filepath.Join("templates", views.Form),
+	}
+}
+
+type ViewConfig struct {
+	Model   string
+	Flavor  string          // templ, react, vue, gohtml
+	Fields  []*HandlerField // Fields of the model the views show
+	Route   string          // Path the handlers of the model are registered at
+	Actions []string        // index and/or show; both when empty
+}
+
+// ViewGenerator writes the list and detail views the index and show handlers
+// of a model render.
+type ViewGenerator struct {
+	model   string
+	flavor  string
+	fields  []*HandlerField
+	route   string
+	actions []string
+}
+
+func NewViewGenerator(vc *ViewConfig) *ViewGenerator {
+	actions := vc.Actions
+	if len(actions) == 0 {
+		actions = []string{"index", "show"}
+	}
+	return &ViewGenerator{vc.Model, vc.Flavor, vc.Fields, vc.Route, actions}
+}
+
+func (vg *ViewGenerator) stubName(action string) string {
+	return vg.flavor + "_" + action + ".txt"
+}
+
+func (vg *ViewGenerator) Generate() error {
+	if !slices.Contains(formFlavors, vg.flavor) {
+		return fmt.Errorf("unknown view flavor %q (expected one of: %s)", vg.flavor, strings.Join(formFlavors, ", "))
+	}
+
+	moduleName := ""
+	if vg.flavor == "templ" {
+		var err error
+		if moduleName, err = GetModuleName(); err != nil {
+			return err
+		}
+	}
+	tmplData := map[string]interface{}{
+		"Module": moduleName,
+		"Model":  vg.model,
+		"Plural": pluralize.NewClient().Plural(vg.model),
+		"Route":  vg.route,
+		"Fields": vg.fields,
+		"Views":  resourceViews(vg.flavor, vg.model),
+	}
+
+	files := resourceViewFiles(vg.flavor, vg.model)
+	for _, action := range vg.actions {
+		output, err := ParseTemplate(tmplData, loadStub(vg.stubName(action)), CommonFuncs)
+		if err != nil {
+			return err
+		}
+		file := files.Index
+		if action == "show" {
+			file = files.Show
+		}
+		if err := writeGeneratedFile(filepath.ToSlash(file), []byte(output)); err != nil {
+			return err
+		}
+	}
+	return nil
+}